@@ -0,0 +1,158 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/adi-253/Gitify/cmd/utils"
+	"github.com/spf13/cobra"
+)
+
+var accountCmd = &cobra.Command{
+	Use:   "account",
+	Short: "Manage the Spotify accounts Gitify is logged into",
+	Long: `Gitify can stay logged into several Spotify accounts, each with its own token
+and profile. Commands act on the active account, chosen with "account use", the
+GITIFY_ACCOUNT environment variable or the --account flag (in increasing order of
+precedence). Until another one is chosen, the active account is "default".`,
+}
+
+var accountListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List logged in accounts",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		names, err := utils.Accounts()
+		if err != nil {
+			return err
+		}
+
+		active := utils.ActiveAccount()
+		records := make([]accountRecord, len(names))
+		for i, name := range names {
+			records[i] = accountRecord{Name: name, Active: name == active}
+			if p, err := loadAccountProfile(name); err == nil {
+				records[i].User = p.Username
+				records[i].Email = p.Email
+			}
+		}
+
+		return render(cmd, records, func(w io.Writer) {
+			if len(records) == 0 {
+				fmt.Fprintln(w, "No accounts yet. Add one with `gitify spotify account add <name>`")
+				return
+			}
+			for _, r := range records {
+				marker := " "
+				if r.Active {
+					marker = "*"
+				}
+				user := r.User
+				if r.Email != "" {
+					user += " <" + r.Email + ">"
+				}
+				fmt.Fprintf(w, "%s %-16s %s\n", marker, r.Name, user)
+			}
+		})
+	},
+}
+
+var accountAddCmd = &cobra.Command{
+	Use:   "add <name>",
+	Short: "Login to Spotify as a new named account",
+	Long: `Login to Spotify and store the credentials under a new account name. The first
+account added becomes the active one; switch later with "account use". A name that
+is already taken is refused unless --force is given to log it in again.
+Accepts the same flags as login.`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		name := args[0]
+		if err := utils.ValidateAccountName(name); err != nil {
+			return err
+		}
+		replacing := utils.AccountExists(name)
+		if force, _ := cmd.Flags().GetBool("force"); replacing && !force {
+			return fmt.Errorf("account %q exists, use `gitify spotify account use %s` or remove it first (or --force to log it in again)", name, name)
+		}
+
+		existing, err := utils.Accounts()
+		if err != nil {
+			return err
+		}
+
+		utils.AccountOverride = name
+		if err := runLogin(cmd, loginOptionsFromFlags(cmd)); err != nil {
+			return err
+		}
+
+		if replacing {
+			fmt.Printf("Account %q logged in again\n", name)
+			return nil
+		}
+		if len(existing) == 0 {
+			if err := utils.SetActiveAccount(name); err != nil {
+				return err
+			}
+			fmt.Printf("Account %q added and is now active\n", name)
+			return nil
+		}
+		fmt.Printf("Account %q added. Switch to it with `gitify spotify account use %s`\n", name, name)
+		return nil
+	},
+}
+
+var accountUseCmd = &cobra.Command{
+	Use:               "use <name>",
+	Short:             "Make an account the active one",
+	Args:              cobra.ExactArgs(1),
+	ValidArgsFunction: completeAccounts,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		name := args[0]
+		if err := utils.ValidateAccountName(name); err != nil {
+			return err
+		}
+		if !utils.AccountExists(name) {
+			return fmt.Errorf("no account named %q, add it with `gitify spotify account add %s`", name, name)
+		}
+
+		if err := utils.SetActiveAccount(name); err != nil {
+			return err
+		}
+		fmt.Printf("Now using account %q\n", name)
+		return nil
+	},
+}
+
+var accountRemoveCmd = &cobra.Command{
+	Use:               "remove <name>",
+	Aliases:           []string{"rm"},
+	Short:             "Delete an account's stored token and profile",
+	Args:              cobra.ExactArgs(1),
+	ValidArgsFunction: completeAccounts,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		name := args[0]
+		if err := utils.ValidateAccountName(name); err != nil {
+			return err
+		}
+		if !utils.AccountExists(name) {
+			return fmt.Errorf("no account named %q", name)
+		}
+
+		if err := utils.RemoveAccount(name); err != nil {
+			return fmt.Errorf("could not remove account %q: %v", name, err)
+		}
+		fmt.Printf("Removed account %q\n", name)
+		return nil
+	},
+}
+
+func init() {
+	addLoginFlags(accountAddCmd)
+	accountAddCmd.Flags().Bool("force", false, "log in again if the account already exists, replacing its credentials")
+
+	accountCmd.AddCommand(accountListCmd)
+	accountCmd.AddCommand(accountAddCmd)
+	accountCmd.AddCommand(accountUseCmd)
+	accountCmd.AddCommand(accountRemoveCmd)
+	spotifyCmd.AddCommand(accountCmd)
+}
@@ -0,0 +1,183 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"strings"
+	"time"
+
+	"github.com/adi-253/Gitify/cmd/utils"
+	"github.com/spf13/cobra"
+)
+
+var authCmd = &cobra.Command{
+	Use:   "auth",
+	Short: "Inspect the stored Spotify credentials",
+}
+
+// authStatus is what `auth status` reports, also its --output record
+type authStatus struct {
+	Account      string     `json:"account"`
+	LoggedIn     bool       `json:"logged_in"`
+	Credentials  string     `json:"credentials,omitempty"`
+	User         string     `json:"user,omitempty"`
+	UserID       string     `json:"user_id,omitempty"`
+	Email        string     `json:"email,omitempty"`
+	Scopes       []string   `json:"scopes,omitempty"`
+	ExpiresAt    *time.Time `json:"expires_at,omitempty"`
+	Expired      bool       `json:"expired"`
+	RefreshOK    bool       `json:"refresh_ok"`
+	RefreshError string     `json:"refresh_error,omitempty"`
+}
+
+var authStatusCmd = &cobra.Command{
+	Use:   "status",
+	Short: "Show who is logged in, the granted scopes and when the token expires",
+	Long: `Show the active account's login: the Spotify user, the scopes the token was
+granted and when the access token expires. The refresh token is exercised to check
+it still works, which also renews the access token.
+
+Exits with status 1 when not logged in or when the refresh fails.`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		applyJSONAlias(cmd)
+
+		status := authStatus{Account: utils.ActiveAccount()}
+		status.Credentials, _ = utils.CredentialsLocation()
+
+		var statusErr error
+		if _, err := utils.StoredToken(); errors.Is(err, fs.ErrNotExist) {
+			status.Credentials = ""
+			statusErr = fmt.Errorf("not logged in, run `gitify spotify login`")
+		} else if err != nil {
+			return fmt.Errorf("failed to load credentials: %w", err)
+		} else {
+			status.LoggedIn = true
+
+			if err := utils.RefreshToken(cmd.Context()); err != nil {
+				status.RefreshError = describeError(err)
+				statusErr = fmt.Errorf("token refresh failed: %s", status.RefreshError)
+			} else {
+				status.RefreshOK = true
+			}
+
+			// Read the token again so a successful refresh shows its new expiry
+			token, err := utils.StoredToken()
+			if err != nil {
+				return fmt.Errorf("failed to load credentials: %w", err)
+			}
+			status.Scopes = token.Scopes()
+			if !token.ExpiresAt.IsZero() {
+				expiresAt := token.ExpiresAt
+				status.ExpiresAt = &expiresAt
+				status.Expired = time.Now().After(expiresAt)
+			}
+
+			if p, err := loadProfile(); err == nil {
+				status.User = p.Username
+				status.UserID = p.Userid
+				status.Email = p.Email
+			}
+		}
+
+		err := render(cmd, status, func(w io.Writer) {
+			printAuthStatus(w, status)
+		})
+		if err != nil {
+			return err
+		}
+		return statusErr
+	},
+}
+
+func printAuthStatus(w io.Writer, s authStatus) {
+	fmt.Fprintf(w, "Account:      %s\n", s.Account)
+	if !s.LoggedIn {
+		fmt.Fprintln(w, "Logged in:    no")
+		return
+	}
+	fmt.Fprintf(w, "Credentials:  %s\n", s.Credentials)
+
+	user := s.User
+	if user == "" {
+		user = "unknown (profile not cached)"
+	}
+	if s.UserID != "" {
+		user += " (" + s.UserID + ")"
+	}
+	if s.Email != "" {
+		user += " <" + s.Email + ">"
+	}
+	fmt.Fprintf(w, "User:         %s\n", user)
+
+	scopes := strings.Join(s.Scopes, ", ")
+	if scopes == "" {
+		scopes = "none recorded"
+	}
+	fmt.Fprintf(w, "Scopes:       %s\n", scopes)
+
+	switch {
+	case s.ExpiresAt == nil:
+		fmt.Fprintln(w, "Expires:      unknown")
+	case s.Expired:
+		fmt.Fprintf(w, "Expires:      %s (expired)\n", s.ExpiresAt.Local().Format(time.RFC1123))
+	default:
+		fmt.Fprintf(w, "Expires:      %s (in %s)\n", s.ExpiresAt.Local().Format(time.RFC1123), time.Until(*s.ExpiresAt).Round(time.Second))
+	}
+
+	if s.RefreshOK {
+		fmt.Fprintln(w, "Refresh:      ok")
+	} else {
+		fmt.Fprintf(w, "Refresh:      failed: %s\n", s.RefreshError)
+	}
+}
+
+var logoutCmd = &cobra.Command{
+	Use:   "logout",
+	Short: "Remove the stored token and profile of the active account",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		applyJSONAlias(cmd)
+
+		account := utils.ActiveAccount()
+		removed, err := utils.Logout(account)
+		if err != nil {
+			return fmt.Errorf("could not remove credentials: %v", err)
+		}
+
+		record := logoutRecord{Account: account, Removed: append([]string{}, removed...)}
+		return render(cmd, record, func(w io.Writer) {
+			if len(removed) == 0 {
+				fmt.Fprintf(w, "Account %q was not logged in\n", account)
+				return
+			}
+			for _, path := range removed {
+				fmt.Fprintln(w, "Removed", path)
+			}
+			fmt.Fprintf(w, "Logged out of account %q\n", account)
+		})
+	},
+}
+
+// addJSONAlias keeps the --json flag auth status and logout had before --output existed,
+// hidden so help only shows --output
+func addJSONAlias(cmd *cobra.Command) {
+	cmd.Flags().Bool("json", false, "same as --output json")
+	cmd.Flags().MarkHidden("json")
+}
+
+func applyJSONAlias(cmd *cobra.Command) {
+	if asJSON, _ := cmd.Flags().GetBool("json"); asJSON {
+		outputFormat = outputJSON
+	}
+}
+
+func init() {
+	addJSONAlias(authStatusCmd)
+	addJSONAlias(logoutCmd)
+	authCmd.AddCommand(authStatusCmd)
+	spotifyCmd.AddCommand(authCmd)
+	spotifyCmd.AddCommand(logoutCmd)
+}
@@ -1,14 +1,61 @@
 package cmd
 
 import (
+	"sync"
+
+	"github.com/adi-253/Gitify/cmd/utils"
 	"github.com/spf13/cobra"
 )
 
 var spotifyCmd = &cobra.Command{
-	Use: "spotify",
+	Use:   "spotify",
 	Short: "Base command for all spotify commands",
+	PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
+		// Catch a bad --account/GITIFY_ACCOUNT before it surfaces as a missing file
+		if err := utils.ValidateAccountName(utils.ActiveAccount()); err != nil {
+			return err
+		}
+		// Before anything reads the token or profile, so every command sees them
+		utils.MigrateLegacyFiles()
+		if err := validateOutputFormat(); err != nil {
+			return err
+		}
+		return ensureScopes(cmd)
+	},
 }
 
-func init(){
+// One SpotifyClient per process so the token stays in memory and connections are reused
+var (
+	sharedClient   *utils.SpotifyClient
+	sharedClientMu sync.Mutex
+)
+
+// spotifyClient returns the shared client, creating it on first use. Failures are
+// not cached, so a later call after logging in succeeds.
+func spotifyClient() (*utils.SpotifyClient, error) {
+	sharedClientMu.Lock()
+	defer sharedClientMu.Unlock()
+
+	if sharedClient == nil {
+		client, err := utils.NewSpotifyClient()
+		if err != nil {
+			return nil, err
+		}
+		sharedClient = client
+	}
+	return sharedClient, nil
+}
+
+// dropSharedClient forgets the shared client, e.g. after a login replaced the token
+// it holds, so the next spotifyClient call reads the new one
+func dropSharedClient() {
+	sharedClientMu.Lock()
+	sharedClient = nil
+	sharedClientMu.Unlock()
+}
+
+func init() {
+	spotifyCmd.PersistentFlags().StringVar(&utils.AccountOverride, "account", "", "account to use (default from GITIFY_ACCOUNT or `spotify account use`)")
+	spotifyCmd.RegisterFlagCompletionFunc("account", completeAccounts)
 	rootCmd.AddCommand(spotifyCmd)
-}
\ No newline at end of file
+}
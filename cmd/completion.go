@@ -0,0 +1,173 @@
+package cmd
+
+import (
+	"strings"
+	"time"
+
+	"github.com/adi-253/Gitify/cmd/utils"
+	"github.com/spf13/cobra"
+)
+
+// Completions come from the cache while it is younger than this, so pressing tab
+// doesn't call Spotify every time
+const completionCacheTTL = 5 * time.Minute
+
+// Cache entries behind completion
+const (
+	playlistsCache    = "playlists"
+	recentTracksCache = "recent-tracks"
+)
+
+// recentTracksMax caps how many search results are remembered for completion
+const recentTracksMax = 50
+
+// recentTracksTTL is how long search results stay around for completion
+const recentTracksTTL = 7 * 24 * time.Hour
+
+// saveForCompletion stores a fresh copy of a list that completion offers. A failed
+// write only costs stale or missing suggestions, so the error is dropped.
+func saveForCompletion(name string, v any) {
+	_ = utils.WriteCache(name, v)
+}
+
+// unattended turns off the passphrase prompt for commands nobody is there to answer,
+// such as a tab press or a status bar. An encrypted token then needs GITIFY_PASSPHRASE
+// or GITIFY_KEY_FILE; without them the command fails instead of hanging.
+func unattended() {
+	utils.NoPrompt = true
+}
+
+// completePlaylists suggests playlist names, described by their IDs, or IDs when the
+// word typed so far only matches those
+func completePlaylists(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	if len(args) > 0 {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+
+	var playlists []Playlist
+	if !utils.ReadCache(playlistsCache, completionCacheTTL, &playlists) {
+		unattended()
+		_, fetched, err := userPlaylists(cmd.Context())
+		if err != nil {
+			cobra.CompDebugln(err.Error(), true)
+			return nil, cobra.ShellCompDirectiveNoFileComp
+		}
+		playlists = fetched
+	}
+
+	var suggestions []string
+	for _, p := range playlists {
+		switch {
+		case hasPrefixFold(p.Name, toComplete):
+			suggestions = append(suggestions, p.Name+"\t"+p.ID)
+		case strings.HasPrefix(p.ID, toComplete):
+			suggestions = append(suggestions, p.ID+"\t"+p.Name)
+		}
+	}
+	return suggestions, cobra.ShellCompDirectiveNoFileComp
+}
+
+// completeRecentSearches suggests the names of tracks found by recent searches
+func completeRecentSearches(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	if len(args) > 0 {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+
+	var suggestions []string
+	seen := map[string]bool{}
+	for _, t := range recentTracks() {
+		if hasPrefixFold(t.Name, toComplete) && !seen[t.Name] {
+			seen[t.Name] = true
+			suggestions = append(suggestions, t.Name+"\t"+strings.Join(t.Artists, ", "))
+		}
+	}
+	return suggestions, cobra.ShellCompDirectiveNoFileComp
+}
+
+// completeTrackURIs suggests the URIs of tracks found by recent searches, described
+// by their names
+func completeTrackURIs(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	if len(args) > 0 {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+
+	var suggestions []string
+	for _, t := range recentTracks() {
+		if strings.HasPrefix(t.URI, toComplete) {
+			suggestions = append(suggestions, t.URI+"\t"+t.Name+" — "+strings.Join(t.Artists, ", "))
+		}
+	}
+	return suggestions, cobra.ShellCompDirectiveNoFileComp
+}
+
+// completeAccounts suggests the names of logged in accounts
+func completeAccounts(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	if len(args) > 0 {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+	names, err := utils.Accounts()
+	if err != nil {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+	return names, cobra.ShellCompDirectiveNoFileComp
+}
+
+// completeConfig suggests config keys, then values for the keys with a fixed set
+func completeConfig(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	switch {
+	case len(args) == 0:
+		keys := utils.ConfigKeys()
+		for _, action := range keybindingActions() {
+			keys = append(keys, "keybindings."+action)
+		}
+		var suggestions []string
+		seen := map[string]bool{}
+		for _, key := range keys {
+			if seen[key] {
+				continue
+			}
+			seen[key] = true
+			setting, _ := utils.FindSetting(key)
+			suggestions = append(suggestions, key+"\t"+setting.Usage)
+		}
+		return suggestions, cobra.ShellCompDirectiveNoFileComp
+	case len(args) == 1 && cmd.Name() == "set":
+		switch args[0] {
+		case "theme":
+			return themeNames(), cobra.ShellCompDirectiveNoFileComp
+		case "auth_flow":
+			return []string{utils.AuthFlowCode, utils.AuthFlowPKCE}, cobra.ShellCompDirectiveNoFileComp
+		}
+	}
+	return nil, cobra.ShellCompDirectiveNoFileComp
+}
+
+// recentTracks returns the remembered search results, newest first
+func recentTracks() []trackRecord {
+	var tracks []trackRecord
+	utils.ReadCache(recentTracksCache, recentTracksTTL, &tracks)
+	return tracks
+}
+
+// rememberTracks adds search results to the ones offered for completion
+func rememberTracks(tracks []trackRecord) {
+	merged := append([]trackRecord{}, tracks...)
+	seen := map[string]bool{}
+	for _, t := range tracks {
+		seen[t.URI] = true
+	}
+	for _, t := range recentTracks() {
+		if !seen[t.URI] {
+			seen[t.URI] = true
+			merged = append(merged, t)
+		}
+	}
+	if len(merged) > recentTracksMax {
+		merged = merged[:recentTracksMax]
+	}
+	saveForCompletion(recentTracksCache, merged)
+}
+
+func hasPrefixFold(s, prefix string) bool {
+	return len(s) >= len(prefix) && strings.EqualFold(s[:len(prefix)], prefix)
+}
@@ -0,0 +1,150 @@
+package cmd
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/adi-253/Gitify/cmd/utils"
+	"github.com/spf13/cobra"
+)
+
+var configCmd = &cobra.Command{
+	Use:   "config",
+	Short: "Read and change Gitify settings",
+	Long: `Read and change the settings stored in Gitify's config file (config.yaml in the
+config directory, or the file given with --config / GITIFY_CONFIG).
+
+Each setting is resolved in this order: command line flag, environment variable
+(including .env), config file, built-in default. Keys:
+
+` + configKeysHelp() + `
+TUI keys are rebound with keybindings.<action> (actions: ` + strings.Join(keybindingActions(), ", ") + `),
+e.g. gitify config set keybindings.quit "q,ctrl+c"`,
+}
+
+var configPathCmd = &cobra.Command{
+	Use:   "path",
+	Short: "Print the config file location",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		path, err := utils.ConfigFilePath()
+		if err != nil {
+			return err
+		}
+		fmt.Println(path)
+		return nil
+	},
+}
+
+var configListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "Show every setting, its value and where the value comes from",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		for _, key := range utils.ConfigKeys() {
+			setting, _ := utils.FindSetting(key)
+			value, source := utils.ConfigValue(key)
+			if setting.Secret && value != "" {
+				value = "********"
+			}
+			fmt.Printf("%-22s %-32s (%s)\n", key, value, source)
+		}
+		return nil
+	},
+}
+
+var configGetCmd = &cobra.Command{
+	Use:               "get <key>",
+	Short:             "Print the effective value of a setting",
+	Args:              cobra.ExactArgs(1),
+	ValidArgsFunction: completeConfig,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if _, ok := utils.FindSetting(args[0]); !ok {
+			return fmt.Errorf("unknown config key %q, see `gitify config list`", args[0])
+		}
+		value, _ := utils.ConfigValue(args[0])
+		fmt.Println(value)
+		return nil
+	},
+}
+
+var configSetCmd = &cobra.Command{
+	Use:               "set <key> <value>",
+	Short:             "Store a setting in the config file",
+	Args:              cobra.ExactArgs(2),
+	ValidArgsFunction: completeConfig,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		key, value := args[0], args[1]
+		if err := validateTUISetting(key, value); err != nil {
+			return err
+		}
+		if err := utils.SetConfigValue(key, value); err != nil {
+			return err
+		}
+
+		if setting, _ := utils.FindSetting(key); setting.Env != "" {
+			if _, source := utils.ConfigValue(key); source != "file" {
+				fmt.Printf("Saved, but %s is set and takes precedence over the file\n", setting.Env)
+				return nil
+			}
+		}
+		fmt.Printf("%s = %s\n", key, value)
+		return nil
+	},
+}
+
+var configUnsetCmd = &cobra.Command{
+	Use:               "unset <key>",
+	Short:             "Remove a setting from the config file",
+	Args:              cobra.ExactArgs(1),
+	ValidArgsFunction: completeConfig,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return utils.UnsetConfigValue(args[0])
+	},
+}
+
+// validateTUISetting checks the keybinding actions, which only the TUI understands
+func validateTUISetting(key, value string) error {
+	if action, ok := strings.CutPrefix(key, "keybindings."); ok {
+		km := defaultKeyMap()
+		if _, ok := km.bindings()[action]; !ok {
+			return fmt.Errorf("unknown keybinding action %q, available: %s", action, strings.Join(keybindingActions(), ", "))
+		}
+		if strings.Trim(value, ", ") == "" {
+			return fmt.Errorf("keybindings.%s needs at least one key", action)
+		}
+	}
+	return nil
+}
+
+func keybindingActions() []string {
+	km := defaultKeyMap()
+	var actions []string
+	for action := range km.bindings() {
+		actions = append(actions, action)
+	}
+	sort.Strings(actions)
+	return actions
+}
+
+func configKeysHelp() string {
+	var b strings.Builder
+	for _, s := range utils.Settings {
+		env := ""
+		if s.Env != "" {
+			env = " [$" + s.Env + "]"
+		}
+		fmt.Fprintf(&b, "  %-18s %s%s\n", s.Key, s.Usage, env)
+	}
+	return b.String()
+}
+
+func init() {
+	configCmd.AddCommand(configPathCmd)
+	configCmd.AddCommand(configListCmd)
+	configCmd.AddCommand(configGetCmd)
+	configCmd.AddCommand(configSetCmd)
+	configCmd.AddCommand(configUnsetCmd)
+	rootCmd.AddCommand(configCmd)
+}
@@ -0,0 +1,76 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+
+	"github.com/adi-253/Gitify/cmd/fakespotify"
+	"github.com/spf13/cobra"
+)
+
+var devCmd = &cobra.Command{
+	Use:   "dev",
+	Short: "Tools for developing and testing Gitify",
+}
+
+var fakeServerCmd = &cobra.Command{
+	Use:   "fake-server",
+	Short: "Run an in-memory fake of the Spotify Web API",
+	Long: `Run an in-memory fake of the Spotify endpoints Gitify uses (profile, playlists,
+search, player, queue and token). Playback state is kept in memory, so pause,
+resume, next, prev, seek, volume, shuffle, repeat, transfer and queue add change
+what the player endpoints return.
+
+Point Gitify at it with the environment variables it prints on startup, then run
+"gitify spotify login" as usual: the fake authorize page redirects straight back.`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		addr, _ := cmd.Flags().GetString("addr")
+		failStatus, _ := cmd.Flags().GetInt("fail-status")
+		failCount, _ := cmd.Flags().GetInt("fail-count")
+		retryAfter, _ := cmd.Flags().GetInt("retry-after")
+		noDevice, _ := cmd.Flags().GetBool("no-device")
+
+		ln, err := net.Listen("tcp", addr)
+		if err != nil {
+			return fmt.Errorf("could not listen on %s: %v", addr, err)
+		}
+
+		base := "http://" + ln.Addr().String()
+		fmt.Println("Fake Spotify server listening on", base)
+		fmt.Println()
+		fmt.Println("Use it with:")
+		fmt.Printf("  export SPOTIFY_API_BASE_URL=%s/v1\n", base)
+		fmt.Printf("  export SPOTIFY_ACCOUNTS_BASE_URL=%s\n", base)
+		fmt.Println()
+		fmt.Printf("Pre-issued access token: %s (refresh token: %s)\n", fakespotify.DefaultAccessToken, fakespotify.DefaultRefreshToken)
+
+		server := fakespotify.New()
+		if failStatus != 0 {
+			server.InjectFailures(failStatus, failCount, retryAfter)
+		}
+		if noDevice {
+			server.SetDeviceActive(false)
+		}
+
+		// Stop on Ctrl+C so the command returns and exits like any other
+		srv := &http.Server{Handler: server}
+		context.AfterFunc(cmd.Context(), func() { srv.Close() })
+		if err := srv.Serve(ln); err != http.ErrServerClosed {
+			return err
+		}
+		return nil
+	},
+}
+
+func init() {
+	fakeServerCmd.Flags().String("addr", "127.0.0.1:8888", "address to listen on")
+	fakeServerCmd.Flags().Int("fail-status", 0, "fail the first Web API requests with this status (e.g. 429, 503)")
+	fakeServerCmd.Flags().Int("fail-count", 1, "how many requests --fail-status applies to")
+	fakeServerCmd.Flags().Int("retry-after", 1, "Retry-After seconds sent with injected 429s")
+	fakeServerCmd.Flags().Bool("no-device", false, "start with no active playback device")
+
+	devCmd.AddCommand(fakeServerCmd)
+	rootCmd.AddCommand(devCmd)
+}
@@ -0,0 +1,228 @@
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/adi-253/Gitify/cmd/utils"
+	"github.com/spf13/cobra"
+)
+
+// Device is one of the user's Spotify Connect devices
+type Device struct {
+	ID            string `json:"id"`
+	Name          string `json:"name"`
+	Type          string `json:"type"`
+	IsActive      bool   `json:"is_active"`
+	IsRestricted  bool   `json:"is_restricted"`
+	VolumePercent *int   `json:"volume_percent"` // null when the device has no volume control
+}
+
+type devicesResponse struct {
+	Devices []Device `json:"devices"`
+}
+
+type deviceRecord struct {
+	ID         string `json:"id"`
+	Name       string `json:"name"`
+	Type       string `json:"type"`
+	Volume     int    `json:"volume"`
+	Active     bool   `json:"active"`
+	Restricted bool   `json:"restricted"`
+}
+
+// deviceOverride is set from the --device flag of the playback commands
+var deviceOverride string
+
+// devicesCache is the cache entry behind device completion, kept briefly since
+// devices come and go
+const (
+	devicesCache    = "devices"
+	devicesCacheTTL = 30 * time.Second
+)
+
+// GetDevices lists the devices Spotify can play on
+func GetDevices(ctx context.Context, client *utils.SpotifyClient) ([]Device, error) {
+	resp, err := client.GetContext(ctx, client.APIURL("/me/player/devices"))
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if err := utils.CheckResponse(resp); err != nil {
+		return nil, err
+	}
+
+	var result devicesResponse
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return nil, err
+	}
+	saveForCompletion(devicesCache, result.Devices)
+	return result.Devices, nil
+}
+
+// TransferPlayback moves playback to a device, starting it when play is set
+func TransferPlayback(ctx context.Context, client *utils.SpotifyClient, deviceID string, play bool) error {
+	body, err := json.Marshal(map[string]any{"device_ids": []string{deviceID}, "play": play})
+	if err != nil {
+		return err
+	}
+
+	resp, err := client.PutContext(ctx, client.APIURL("/me/player"), bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	return utils.CheckResponse(resp)
+}
+
+// resolveDevice finds a device by ID or by name, ignoring case
+func resolveDevice(ctx context.Context, client *utils.SpotifyClient, ref string) (Device, error) {
+	devices, err := GetDevices(ctx, client)
+	if err != nil {
+		return Device{}, err
+	}
+
+	for _, d := range devices {
+		if d.ID == ref {
+			return d, nil
+		}
+	}
+	var matches []Device
+	for _, d := range devices {
+		if strings.EqualFold(d.Name, ref) {
+			matches = append(matches, d)
+		}
+	}
+	switch len(matches) {
+	case 0:
+		return Device{}, fmt.Errorf("no device named %q, see `gitify spotify devices`", ref)
+	case 1:
+		return matches[0], nil
+	default:
+		return Device{}, fmt.Errorf("%d devices are named %q, use the ID instead", len(matches), ref)
+	}
+}
+
+// addDeviceFlag gives a playback command the --device flag
+func addDeviceFlag(cmd *cobra.Command) {
+	cmd.Flags().StringVar(&deviceOverride, "device", "", "device name or ID to play on (default the active device, then the default_device setting)")
+	cmd.RegisterFlagCompletionFunc("device", completeDevices)
+}
+
+// completeDevices suggests device names, described by their type
+func completeDevices(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	if cmd.Name() == "transfer" && len(args) > 0 {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+
+	var devices []Device
+	if !utils.ReadCache(devicesCache, devicesCacheTTL, &devices) {
+		unattended()
+		client, err := spotifyClient()
+		if err != nil {
+			return nil, cobra.ShellCompDirectiveNoFileComp
+		}
+		if devices, err = GetDevices(cmd.Context(), client); err != nil {
+			cobra.CompDebugln(err.Error(), true)
+			return nil, cobra.ShellCompDirectiveNoFileComp
+		}
+	}
+
+	var suggestions []string
+	for _, d := range devices {
+		switch {
+		case hasPrefixFold(d.Name, toComplete):
+			suggestions = append(suggestions, d.Name+"\t"+d.Type)
+		case strings.HasPrefix(d.ID, toComplete):
+			suggestions = append(suggestions, d.ID+"\t"+d.Name)
+		}
+	}
+	return suggestions, cobra.ShellCompDirectiveNoFileComp
+}
+
+var devicesCmd = &cobra.Command{
+	Use:         "devices",
+	Short:       "List the devices Spotify can play on",
+	Args:        cobra.NoArgs,
+	Annotations: needsScopes("user-read-playback-state"),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		client, err := spotifyClient()
+		if err != nil {
+			return err
+		}
+		devices, err := GetDevices(cmd.Context(), client)
+		if err != nil {
+			return errors.New(describeError(err))
+		}
+
+		records := make([]deviceRecord, len(devices))
+		for i, d := range devices {
+			records[i] = deviceRecord{ID: d.ID, Name: d.Name, Type: d.Type, Active: d.IsActive, Restricted: d.IsRestricted}
+			if d.VolumePercent != nil {
+				records[i].Volume = *d.VolumePercent
+			}
+		}
+
+		return render(cmd, records, func(w io.Writer) {
+			if len(devices) == 0 {
+				fmt.Fprintln(w, "No devices found. Open Spotify on your phone or computer and try again")
+				return
+			}
+			for _, d := range devices {
+				marker := " "
+				if d.IsActive {
+					marker = "*"
+				}
+				volume := "-"
+				if d.VolumePercent != nil {
+					volume = fmt.Sprintf("%d%%", *d.VolumePercent)
+				}
+				fmt.Fprintf(w, "%s %-24s %-12s %4s  %s\n", marker, d.Name, d.Type, volume, d.ID)
+			}
+		})
+	},
+}
+
+var transferCmd = &cobra.Command{
+	Use:   "transfer <device>",
+	Short: "Move playback to another device",
+	Long: `Move playback to a device, given by name (case insensitive) or ID as listed by
+"gitify spotify devices". Playback keeps its current state unless --play is given.`,
+	Args:              cobra.ExactArgs(1),
+	ValidArgsFunction: completeDevices,
+	Annotations:       needsScopes("user-read-playback-state", "user-modify-playback-state"),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		play, _ := cmd.Flags().GetBool("play")
+
+		client, err := spotifyClient()
+		if err != nil {
+			return err
+		}
+		device, err := resolveDevice(cmd.Context(), client, args[0])
+		if err != nil {
+			return errors.New(describeError(err))
+		}
+		if err := TransferPlayback(cmd.Context(), client, device.ID, play); err != nil {
+			return errors.New(describeError(err))
+		}
+
+		return render(cmd, playbackRecord{Action: "transfer", Device: device.Name}, func(w io.Writer) {
+			fmt.Fprintf(w, "Playback moved to %s\n", device.Name)
+		})
+	},
+}
+
+func init() {
+	transferCmd.Flags().Bool("play", false, "start playing on the device")
+
+	spotifyCmd.AddCommand(devicesCmd)
+	spotifyCmd.AddCommand(transferCmd)
+}
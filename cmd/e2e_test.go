@@ -0,0 +1,525 @@
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/adi-253/Gitify/cmd/fakespotify"
+	"github.com/adi-253/Gitify/cmd/utils"
+	"github.com/spf13/cobra"
+)
+
+// startFake runs the fake Spotify server and points gitify at it, logged in with the
+// fake's default tokens and a config directory of its own
+func startFake(t *testing.T) (*fakespotify.Server, *utils.FileStore) {
+	t.Helper()
+	fake := fakespotify.New()
+	srv := httptest.NewServer(fake)
+	t.Cleanup(srv.Close)
+
+	dir := t.TempDir()
+	t.Setenv("GITIFY_CONFIG_DIR", dir)
+	t.Setenv("GITIFY_CONFIG", filepath.Join(dir, "config.yaml"))
+	t.Setenv("SPOTIFY_API_BASE_URL", srv.URL+"/v1")
+	t.Setenv("SPOTIFY_ACCOUNTS_BASE_URL", srv.URL)
+	t.Setenv("CLIENT_ID", "test-client")
+	t.Setenv("CLIENT_SECRET", "")
+
+	store := &utils.FileStore{Path: filepath.Join(dir, "token.json")}
+	err := store.Save(&utils.SpotfiyToken{
+		AccessToken:  fakespotify.DefaultAccessToken,
+		RefreshToken: fakespotify.DefaultRefreshToken,
+		TokenType:    "Bearer",
+		Scope:        fakespotify.DefaultScope,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Short waits so retries don't slow the tests down
+	oldStore, oldPolicy := utils.Credentials, utils.DefaultRetryPolicy
+	utils.Credentials = store
+	utils.DefaultRetryPolicy = utils.RetryPolicy{MaxRetries: 2, BaseDelay: time.Millisecond, MaxDelay: 10 * time.Millisecond}
+	sharedClient = nil
+	t.Cleanup(func() {
+		utils.Credentials, utils.DefaultRetryPolicy = oldStore, oldPolicy
+		// Flag variables outlive a command run
+		sharedClient, deviceOverride = nil, ""
+	})
+	return fake, store
+}
+
+// gitify runs a command line against the fake server and returns what it printed
+func gitify(t *testing.T, args ...string) (string, error) {
+	t.Helper()
+	var out bytes.Buffer
+	rootCmd.SetOut(&out)
+	rootCmd.SetErr(&out)
+	rootCmd.SetArgs(args)
+	err := rootCmd.ExecuteContext(context.Background())
+	return out.String(), err
+}
+
+func TestPlayPauseNext(t *testing.T) {
+	fake, _ := startFake(t)
+
+	if out, err := gitify(t, "spotify", "play", "spotify:track:track02", "https://open.spotify.com/track/track03"); err != nil {
+		t.Fatalf("play: %v\n%s", err, out)
+	}
+	if uri, playing := fake.NowPlaying(); uri != "spotify:track:track02" || !playing {
+		t.Errorf("after play: %s, playing %v; want spotify:track:track02 playing", uri, playing)
+	}
+
+	if out, err := gitify(t, "spotify", "pause"); err != nil {
+		t.Fatalf("pause: %v\n%s", err, out)
+	}
+	if _, playing := fake.NowPlaying(); playing {
+		t.Error("still playing after pause")
+	}
+
+	out, err := gitify(t, "spotify", "next")
+	if err != nil {
+		t.Fatalf("next: %v\n%s", err, out)
+	}
+	if !strings.Contains(out, "Skipped to next track") {
+		t.Errorf("next printed %q", out)
+	}
+	if uri, _ := fake.NowPlaying(); uri != "spotify:track:track03" {
+		t.Errorf("after next: %s, want spotify:track:track03", uri)
+	}
+}
+
+func TestRetriesTransientFailures(t *testing.T) {
+	fake, _ := startFake(t)
+
+	// Within the retry budget the command succeeds
+	fake.InjectFailures(503, 2, 0)
+	if out, err := gitify(t, "spotify", "pause"); err != nil {
+		t.Fatalf("pause after two 503s: %v\n%s", err, out)
+	}
+	if _, playing := fake.NowPlaying(); playing {
+		t.Error("still playing after pause")
+	}
+
+	// Past it, a rate limit is reported as one
+	fake.InjectFailures(429, 3, 0)
+	_, err := gitify(t, "spotify", "resume")
+	if err == nil || !strings.Contains(err.Error(), "rate limiting") {
+		t.Errorf("resume after three 429s: %v, want a rate limit error", err)
+	}
+}
+
+func TestDoesNotRetryPostOnGatewayError(t *testing.T) {
+	fake, _ := startFake(t)
+	if out, err := gitify(t, "spotify", "play", "spotify:track:track01", "spotify:track:track02"); err != nil {
+		t.Fatalf("play: %v\n%s", err, out)
+	}
+
+	// Spotify may already have skipped, so a retry could skip twice
+	fake.InjectFailures(503, 1, 0)
+	if _, err := gitify(t, "spotify", "next"); err == nil {
+		t.Error("next after a 503 succeeded, want the failure reported")
+	}
+	if uri, _ := fake.NowPlaying(); uri != "spotify:track:track01" {
+		t.Errorf("after a failed next: %s, want spotify:track:track01 as the POST is sent once", uri)
+	}
+
+	// A rate limited POST was never acted on and is retried
+	fake.InjectFailures(429, 1, 0)
+	if out, err := gitify(t, "spotify", "next"); err != nil {
+		t.Fatalf("next after a 429: %v\n%s", err, out)
+	}
+	if uri, _ := fake.NowPlaying(); uri != "spotify:track:track02" {
+		t.Errorf("after next: %s, want spotify:track:track02", uri)
+	}
+}
+
+func TestRefreshesExpiredToken(t *testing.T) {
+	fake, store := startFake(t)
+	if out, err := gitify(t, "spotify", "play", "spotify:track:track01", "spotify:track:track02"); err != nil {
+		t.Fatalf("play: %v\n%s", err, out)
+	}
+	fake.ExpireAccessTokens()
+
+	if out, err := gitify(t, "spotify", "next"); err != nil {
+		t.Fatalf("next with an expired token: %v\n%s", err, out)
+	}
+	token, err := store.Load()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if token.AccessToken == fakespotify.DefaultAccessToken {
+		t.Error("refreshed token was not saved")
+	}
+}
+
+func TestNoActiveDevice(t *testing.T) {
+	fake, _ := startFake(t)
+	if out, err := gitify(t, "spotify", "play", "spotify:track:track01"); err != nil {
+		t.Fatalf("play: %v\n%s", err, out)
+	}
+	fake.SetDeviceActive(false)
+
+	_, err := gitify(t, "spotify", "resume")
+	if err == nil || !strings.Contains(err.Error(), "No active device") {
+		t.Fatalf("resume without a device: %v, want a no active device error", err)
+	}
+
+	// default_device is tried when nothing is active
+	t.Setenv("GITIFY_DEVICE", "Fake Phone")
+	if out, err := gitify(t, "spotify", "resume"); err != nil {
+		t.Fatalf("resume with a default device: %v\n%s", err, out)
+	}
+	if _, playing := fake.NowPlaying(); !playing {
+		t.Error("not playing on the default device")
+	}
+}
+
+func TestRelativeChangesOnAnotherDevice(t *testing.T) {
+	startFake(t)
+	if out, err := gitify(t, "spotify", "play", "spotify:track:track01"); err != nil {
+		t.Fatalf("play: %v\n%s", err, out)
+	}
+
+	// The phone's own volume is changed, not the laptop's
+	out, err := gitify(t, "spotify", "volume", "+5", "--device", "Fake Phone")
+	if err != nil {
+		t.Fatalf("volume +5 on the phone: %v\n%s", err, out)
+	}
+	if !strings.Contains(out, "Volume 45%") {
+		t.Errorf("volume +5 on the phone at 40%% printed %q", out)
+	}
+
+	// Progress and shuffle are only known for the device that is playing
+	if _, err := gitify(t, "spotify", "seek", "+10s", "--device", "Kitchen Speaker"); err == nil {
+		t.Error("relative seek on a device that isn't playing succeeded")
+	}
+	if _, err := gitify(t, "spotify", "shuffle", "toggle", "--device", "Kitchen Speaker"); err == nil {
+		t.Error("shuffle toggle on a device that isn't playing succeeded")
+	}
+}
+
+func TestSearchOnlyNeedsPlaybackScopeToPlay(t *testing.T) {
+	_, store := startFake(t)
+	err := store.Save(&utils.SpotfiyToken{
+		AccessToken:  fakespotify.DefaultAccessToken,
+		RefreshToken: fakespotify.DefaultRefreshToken,
+		TokenType:    "Bearer",
+		Scope:        strings.Join(utils.DefaultScopes, " "),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	out, err := gitify(t, "spotify", "search", "levitating")
+	if err != nil {
+		t.Fatalf("search with the default scopes: %v\n%s", err, out)
+	}
+	if !strings.Contains(out, "Levitating") {
+		t.Errorf("search printed %q", out)
+	}
+
+	t.Cleanup(func() { searchcmd.Flags().Set("first", "false") })
+	_, err = gitify(t, "spotify", "search", "levitating", "--first")
+	if err == nil || !strings.Contains(err.Error(), "user-modify-playback-state") {
+		t.Errorf("search --first without the playback scope: %v, want it to ask for user-modify-playback-state", err)
+	}
+}
+
+func TestDefaultScopesCoverReadCommands(t *testing.T) {
+	_, store := startFake(t)
+	err := store.Save(&utils.SpotfiyToken{
+		AccessToken:  fakespotify.DefaultAccessToken,
+		RefreshToken: fakespotify.DefaultRefreshToken,
+		TokenType:    "Bearer",
+		Scope:        strings.Join(utils.DefaultScopes, " "),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := saveProfile(&Profile{Username: "Fake User", Userid: "fakeuser"}); err != nil {
+		t.Fatal(err)
+	}
+
+	// Reading needs no second consent; only playing does
+	for _, args := range [][]string{
+		{"spotify", "me"},
+		{"spotify", "playlist"},
+		{"spotify", "playlist", "list"},
+		{"spotify", "now"},
+	} {
+		if out, err := gitify(t, args...); err != nil {
+			t.Errorf("%s with the default scopes: %v\n%s", strings.Join(args, " "), err, out)
+		}
+	}
+}
+
+func TestWaybarStatusCachesFailures(t *testing.T) {
+	fake, _ := startFake(t)
+	if out, err := gitify(t, "spotify", "play", "spotify:track:track01"); err != nil {
+		t.Fatalf("play: %v\n%s", err, out)
+	}
+	t.Cleanup(func() {
+		statusCmd.Flags().Set("waybar", "false")
+		statusCmd.Flags().Set("cache-ttl", defaultStatusCacheTTL.String())
+	})
+
+	// More failures than the retries cover
+	fake.InjectFailures(503, 3, 0)
+	out, err := gitify(t, "spotify", "status", "--waybar", "--cache-ttl", "1m")
+	if err != nil {
+		t.Fatalf("status --waybar on a failure: %v, want a JSON line and no error", err)
+	}
+	if !strings.Contains(out, `"class":"error"`) {
+		t.Errorf("status --waybar on a failure printed %q", out)
+	}
+
+	// Spotify is back, but the failure stays cached
+	if out, _ := gitify(t, "spotify", "status", "--waybar", "--cache-ttl", "1m"); !strings.Contains(out, `"class":"error"`) {
+		t.Errorf("status --waybar within the cache TTL printed %q, want the cached error", out)
+	}
+	if out, _ := gitify(t, "spotify", "status", "--waybar", "--cache-ttl", "0"); !strings.Contains(out, `"class":"playing"`) {
+		t.Errorf("status --waybar without the cache printed %q", out)
+	}
+}
+
+func TestMigratesLegacyFilesOnFirstCommand(t *testing.T) {
+	for _, args := range [][]string{
+		{"spotify", "me"},
+		{"spotify", "playlist", "list"},
+		{"spotify", "auth", "status"},
+	} {
+		t.Run(strings.Join(args[1:], " "), func(t *testing.T) {
+			_, store := startFake(t)
+			if err := store.Delete(); err != nil {
+				t.Fatal(err)
+			}
+
+			// An older version left its files in the working directory
+			t.Chdir(t.TempDir())
+			legacy := &utils.FileStore{Path: "token.json"}
+			err := legacy.Save(&utils.SpotfiyToken{
+				AccessToken:  fakespotify.DefaultAccessToken,
+				RefreshToken: fakespotify.DefaultRefreshToken,
+				TokenType:    "Bearer",
+				Scope:        fakespotify.DefaultScope,
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+			profile := `{"display_name": "Fake User", "email": "fake.user@example.com", "id": "fakeuser"}`
+			if err := os.WriteFile("profile.json", []byte(profile), 0600); err != nil {
+				t.Fatal(err)
+			}
+
+			out, err := gitify(t, args...)
+			if err != nil {
+				t.Fatalf("%s after an upgrade: %v\n%s", strings.Join(args, " "), err, out)
+			}
+			if !strings.Contains(out, "Fake User") && !strings.Contains(out, "Road Trip") {
+				t.Errorf("%s after an upgrade printed %q", strings.Join(args, " "), out)
+			}
+			if _, err := store.Load(); err != nil {
+				t.Errorf("token was not moved into the config directory: %v", err)
+			}
+		})
+	}
+}
+
+func TestAuthJSONAlias(t *testing.T) {
+	startFake(t)
+	t.Cleanup(func() {
+		outputFormat = ""
+		authStatusCmd.Flags().Set("json", "false")
+		logoutCmd.Flags().Set("json", "false")
+	})
+
+	out, err := gitify(t, "spotify", "auth", "status", "--json")
+	if err != nil {
+		t.Fatalf("auth status --json: %v\n%s", err, out)
+	}
+	var status authStatus
+	if err := json.Unmarshal([]byte(out), &status); err != nil || !status.LoggedIn {
+		t.Errorf("auth status --json printed %q, want a logged in JSON record", out)
+	}
+
+	out, err = gitify(t, "spotify", "logout", "--json")
+	if err != nil {
+		t.Fatalf("logout --json: %v\n%s", err, out)
+	}
+	var record logoutRecord
+	if err := json.Unmarshal([]byte(out), &record); err != nil || len(record.Removed) == 0 {
+		t.Errorf("logout --json printed %q, want a JSON record of the removed files", out)
+	}
+}
+
+func TestPlaylistByBareID(t *testing.T) {
+	startFake(t)
+	if err := saveProfile(&Profile{Username: "Fake User", Userid: "fakeuser"}); err != nil {
+		t.Fatal(err)
+	}
+
+	// Not in the user's playlists, so only the ID finds it
+	out, err := gitify(t, "spotify", "playlist", "tracks", "37i9dQZF1DXcBWIGoYBM5M")
+	if err != nil {
+		t.Fatalf("playlist tracks by ID: %v\n%s", err, out)
+	}
+	if !strings.Contains(out, "Midnight City") {
+		t.Errorf("playlist tracks by ID printed %q", out)
+	}
+
+	if _, err := gitify(t, "spotify", "playlist", "tracks", "Not A Playlist"); err == nil || !strings.Contains(err.Error(), "no playlist named") {
+		t.Errorf("playlist tracks by an unknown name: %v, want no playlist named", err)
+	}
+}
+
+func TestStatusServesStaleWhileAnotherRefreshes(t *testing.T) {
+	fake, _ := startFake(t)
+	if out, err := gitify(t, "spotify", "play", "spotify:track:track01"); err != nil {
+		t.Fatalf("play: %v\n%s", err, out)
+	}
+	t.Cleanup(func() {
+		statusCmd.Flags().Set("waybar", "false")
+		statusCmd.Flags().Set("cache-ttl", defaultStatusCacheTTL.String())
+	})
+	if out, err := gitify(t, "spotify", "status", "--waybar"); err != nil || !strings.Contains(out, `"class":"playing"`) {
+		t.Fatalf("status --waybar: %v\n%s", err, out)
+	}
+
+	// Another status bar is refreshing the now expired entry
+	unlock, ok := utils.LockCache(statusCache)
+	if !ok {
+		t.Fatal("could not take the status cache lock")
+	}
+	defer unlock()
+	path, err := utils.AccountFile(utils.DefaultAccount, filepath.Join("cache", statusCache+".json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	old := time.Now().Add(-time.Minute)
+	if err := os.Chtimes(path, old, old); err != nil {
+		t.Fatal(err)
+	}
+
+	// So this one shows the old state instead of asking Spotify, which is failing
+	fake.InjectFailures(503, 3, 0)
+	out, err := gitify(t, "spotify", "status", "--waybar")
+	if err != nil || !strings.Contains(out, `"class":"playing"`) {
+		t.Errorf("status --waybar while another refreshes: %v, printed %q, want the stale state", err, out)
+	}
+}
+
+func TestPlayAlbumAndArtistContexts(t *testing.T) {
+	startFake(t)
+	tests := []struct {
+		uri, context, name, track string
+	}{
+		{"spotify:album:FutureNostalgia", "album", "Future Nostalgia", "Levitating"},
+		{"https://open.spotify.com/artist/Queen?si=abc", "artist", "Queen", "Bohemian Rhapsody"},
+		{"spotify:playlist:playlist02", "playlist", "Classics", "Bohemian Rhapsody"},
+	}
+	for _, tt := range tests {
+		if out, err := gitify(t, "spotify", "play", tt.uri); err != nil {
+			t.Fatalf("play %s: %v\n%s", tt.uri, err, out)
+		}
+		out, err := gitify(t, "spotify", "now", "--format", "{{.Context}}|{{.ContextName}}|{{.Track}}")
+		if err != nil {
+			t.Fatalf("now after playing %s: %v\n%s", tt.uri, err, out)
+		}
+		if want := tt.context + "|" + tt.name + "|" + tt.track + "\n"; out != want {
+			t.Errorf("now after playing %s printed %q, want %q", tt.uri, out, want)
+		}
+	}
+	t.Cleanup(func() { nowCmd.Flags().Set("format", "") })
+}
+
+func TestTUILoadsEveryPlaylist(t *testing.T) {
+	startFake(t)
+	initConfig()
+	client, err := spotifyClient()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	msg := loadPlaylistsCmd(context.Background(), client, "fakeuser")()
+	loaded, ok := msg.(playlistsLoadedMsg)
+	if !ok {
+		t.Fatalf("loadPlaylistsCmd returned %#v", msg)
+	}
+	var names []string
+	for _, p := range loaded.playlists {
+		names = append(names, p.Name)
+	}
+	if got, want := strings.Join(names, ", "), "Road Trip, Classics, Long Mix"; got != want {
+		t.Errorf("loaded %s, want %s", got, want)
+	}
+}
+
+// approvingReader answers the --no-browser login prompt: on first read it approves a
+// login at the fake's authorize endpoint and returns the code it redirects back with
+type approvingReader struct {
+	authorizeURL string
+	done         bool
+}
+
+func (r *approvingReader) Read(p []byte) (int, error) {
+	if r.done {
+		return 0, io.EOF
+	}
+	r.done = true
+	client := &http.Client{CheckRedirect: func(*http.Request, []*http.Request) error { return http.ErrUseLastResponse }}
+	resp, err := client.Get(r.authorizeURL)
+	if err != nil {
+		return 0, err
+	}
+	resp.Body.Close()
+	location, err := resp.Location()
+	if err != nil {
+		return 0, err
+	}
+	return copy(p, location.Query().Get("code")+"\n"), nil
+}
+
+func TestReloginReplacesSharedClient(t *testing.T) {
+	startFake(t)
+	t.Setenv("CLIENT_SECRET", "test-secret")
+	initConfig()
+
+	// A client made before the login, holding the old token
+	if _, err := spotifyClient(); err != nil {
+		t.Fatal(err)
+	}
+
+	query := url.Values{"redirect_uri": {"http://127.0.0.1:8080/callback"}, "scope": {fakespotify.DefaultScope + " user-top-read"}}
+	cmd := &cobra.Command{}
+	cmd.SetContext(context.Background())
+	cmd.SetIn(&approvingReader{authorizeURL: utils.AccountsBaseURL + "/authorize?" + query.Encode()})
+	opts := defaultLoginOptions()
+	opts.noBrowser = true
+	opts.scopes = []string{"user-top-read"}
+	if err := runLogin(cmd, opts); err != nil {
+		t.Fatal(err)
+	}
+
+	token, err := utils.StoredToken()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if missing := token.MissingScopes([]string{"user-top-read"}); len(missing) > 0 {
+		t.Errorf("after login the stored token lacks %v", missing)
+	}
+	// The profile lookup during login must already use the new token
+	if sharedClient == nil || sharedClient.Token.AccessToken != token.AccessToken {
+		t.Error("the shared client still holds the token from before the login")
+	}
+}
@@ -0,0 +1,121 @@
+package fakespotify
+
+import (
+	"crypto/sha256"
+	"encoding/base64"
+	"net/http"
+	"net/url"
+)
+
+// handleAuthorize skips the consent screen and sends the browser straight back
+// to redirect_uri with a fresh code, echoing state like the real service. A PKCE
+// code_challenge is remembered so the token exchange can verify it, and the
+// requested scope (DefaultScope when none is asked for) is granted as is.
+func (s *Server) handleAuthorize(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	redirect, err := url.Parse(q.Get("redirect_uri"))
+	if err != nil || redirect.Scheme == "" {
+		http.Error(w, "invalid redirect_uri", http.StatusBadRequest)
+		return
+	}
+
+	if m := q.Get("code_challenge_method"); m != "" && m != "S256" {
+		http.Error(w, "unsupported code_challenge_method", http.StatusBadRequest)
+		return
+	}
+
+	scope := q.Get("scope")
+	if scope == "" {
+		scope = DefaultScope
+	}
+
+	code := randomToken("code-")
+	s.mu.Lock()
+	s.authCodes[code] = authCode{challenge: q.Get("code_challenge"), scope: scope}
+	s.mu.Unlock()
+
+	params := redirect.Query()
+	params.Set("code", code)
+	if state := q.Get("state"); state != "" {
+		params.Set("state", state)
+	}
+	redirect.RawQuery = params.Encode()
+
+	http.Redirect(w, r, redirect.String(), http.StatusFound)
+}
+
+func (s *Server) handleToken(w http.ResponseWriter, r *http.Request) {
+	if err := r.ParseForm(); err != nil {
+		writeTokenError(w, "invalid_request", "could not parse form")
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	resp := map[string]any{
+		"token_type": "Bearer",
+		"expires_in": 3600,
+	}
+
+	// Confidential clients use basic auth; PKCE clients only send client_id
+	_, _, basic := r.BasicAuth()
+	if !basic && r.PostForm.Get("client_id") == "" {
+		writeTokenError(w, "invalid_client", "Missing client authentication")
+		return
+	}
+
+	switch r.PostForm.Get("grant_type") {
+	case "authorization_code":
+		code := r.PostForm.Get("code")
+		auth, ok := s.authCodes[code]
+		if !ok {
+			writeTokenError(w, "invalid_grant", "Invalid authorization code")
+			return
+		}
+		delete(s.authCodes, code)
+
+		if auth.challenge != "" && s256(r.PostForm.Get("code_verifier")) != auth.challenge {
+			writeTokenError(w, "invalid_grant", "code_verifier was incorrect")
+			return
+		}
+		if auth.challenge == "" && !basic {
+			writeTokenError(w, "invalid_client", "Client secret required without PKCE")
+			return
+		}
+
+		refresh := randomToken("refresh-")
+		s.refreshTokens[refresh] = auth.scope
+		resp["refresh_token"] = refresh
+		resp["scope"] = auth.scope
+	case "refresh_token":
+		scope, ok := s.refreshTokens[r.PostForm.Get("refresh_token")]
+		if !ok {
+			writeTokenError(w, "invalid_grant", "Invalid refresh token")
+			return
+		}
+		resp["scope"] = scope
+	default:
+		writeTokenError(w, "unsupported_grant_type", "grant_type must be authorization_code or refresh_token")
+		return
+	}
+
+	access := randomToken("access-")
+	s.accessTokens[access] = resp["scope"].(string)
+	resp["access_token"] = access
+
+	writeJSON(w, http.StatusOK, resp)
+}
+
+func s256(verifier string) string {
+	sum := sha256.Sum256([]byte(verifier))
+	return base64.RawURLEncoding.EncodeToString(sum[:])
+}
+
+// writeTokenError uses the OAuth error shape, which differs from the Web API one
+func writeTokenError(w http.ResponseWriter, code, description string) {
+	writeJSON(w, http.StatusBadRequest, map[string]string{
+		"error":             code,
+		"error_description": description,
+	})
+}
@@ -0,0 +1,111 @@
+package fakespotify
+
+import (
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// Seed data served by the fake. It is deliberately small but large enough that
+// the playlist with many tracks needs more than one page at the default limits.
+
+type fakeUser struct {
+	ID          string
+	DisplayName string
+	Email       string
+}
+
+type fakeTrack struct {
+	ID         string
+	Name       string
+	Artists    []string
+	Album      string
+	DurationMS int
+}
+
+func (t fakeTrack) URI() string { return "spotify:track:" + t.ID }
+
+// Albums and artists are only known by name; their IDs are derived from it, e.g.
+// "After Hours" is spotify:album:AfterHours
+func catalogID(name string) string {
+	return strings.Map(func(r rune) rune {
+		if r < unicode.MaxASCII && (unicode.IsLetter(r) || unicode.IsDigit(r)) {
+			return r
+		}
+		return -1
+	}, name)
+}
+
+type fakePlaylist struct {
+	ID       string
+	Name     string
+	TrackIDs []string
+	// Editorial playlists aren't in the user's library and are only found by ID
+	Editorial bool
+}
+
+func (p fakePlaylist) URI() string { return "spotify:playlist:" + p.ID }
+
+type fakeDevice struct {
+	ID     string
+	Name   string
+	Type   string
+	Volume int
+}
+
+func seedUser() fakeUser {
+	return fakeUser{
+		ID:          "fakeuser",
+		DisplayName: "Fake User",
+		Email:       "fake.user@example.com",
+	}
+}
+
+func seedTracks() []fakeTrack {
+	tracks := []fakeTrack{
+		{ID: "track01", Name: "Blinding Lights", Artists: []string{"The Weeknd"}, Album: "After Hours", DurationMS: 200040},
+		{ID: "track02", Name: "Levitating", Artists: []string{"Dua Lipa", "DaBaby"}, Album: "Future Nostalgia", DurationMS: 203064},
+		{ID: "track03", Name: "Bohemian Rhapsody", Artists: []string{"Queen"}, Album: "A Night at the Opera", DurationMS: 354320},
+		{ID: "track04", Name: "Get Lucky", Artists: []string{"Daft Punk", "Pharrell Williams", "Nile Rodgers"}, Album: "Random Access Memories", DurationMS: 369626},
+		{ID: "track05", Name: "Midnight City", Artists: []string{"M83"}, Album: "Hurry Up, We're Dreaming", DurationMS: 243960},
+		{ID: "track06", Name: "Take On Me", Artists: []string{"a-ha"}, Album: "Hunting High and Low", DurationMS: 225280},
+	}
+
+	// Filler tracks so "Long Mix" pages across several requests
+	for i := 1; i <= 120; i++ {
+		tracks = append(tracks, fakeTrack{
+			ID:         fillerTrackID(i),
+			Name:       "Filler Track " + strconv.Itoa(i),
+			Artists:    []string{"Gitify Test Band"},
+			Album:      "Endless Fixtures",
+			DurationMS: 180000 + i*1000,
+		})
+	}
+	return tracks
+}
+
+func seedPlaylists() []fakePlaylist {
+	long := make([]string, 0, 120)
+	for i := 1; i <= 120; i++ {
+		long = append(long, fillerTrackID(i))
+	}
+
+	return []fakePlaylist{
+		{ID: "playlist01", Name: "Road Trip", TrackIDs: []string{"track01", "track02", "track04", "track06"}},
+		{ID: "playlist02", Name: "Classics", TrackIDs: []string{"track03", "track06"}},
+		{ID: "playlist03", Name: "Long Mix", TrackIDs: long},
+		{ID: "37i9dQZF1DXcBWIGoYBM5M", Name: "Today's Top Hits", TrackIDs: []string{"track05", "track02"}, Editorial: true},
+	}
+}
+
+func seedDevices() []fakeDevice {
+	return []fakeDevice{
+		{ID: "device01", Name: "Fake Laptop", Type: "Computer", Volume: 65},
+		{ID: "device02", Name: "Fake Phone", Type: "Smartphone", Volume: 40},
+		{ID: "device03", Name: "Kitchen Speaker", Type: "Speaker", Volume: 30},
+	}
+}
+
+func fillerTrackID(i int) string {
+	return "filler" + strconv.Itoa(i)
+}
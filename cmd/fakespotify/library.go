@@ -0,0 +1,198 @@
+package fakespotify
+
+import (
+	"net/http"
+	"strings"
+)
+
+func (s *Server) handleMe(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	u := s.user
+	s.mu.Unlock()
+
+	writeJSON(w, http.StatusOK, map[string]any{
+		"id":           u.ID,
+		"display_name": u.DisplayName,
+		"email":        u.Email,
+		"external_urls": map[string]any{
+			"spotify": "https://open.spotify.com/user/" + u.ID,
+		},
+	})
+}
+
+func (s *Server) handlePlaylists(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if id := r.PathValue("id"); id != "" && id != s.user.ID {
+		writeError(w, http.StatusNotFound, "Not found.", "")
+		return
+	}
+
+	var saved []fakePlaylist
+	for _, p := range s.playlists {
+		if !p.Editorial {
+			saved = append(saved, p)
+		}
+	}
+
+	limit, offset := paging(r, 20, 50)
+	total := len(saved)
+
+	items := []map[string]any{}
+	for i := offset; i < total && i < offset+limit; i++ {
+		p := saved[i]
+		items = append(items, map[string]any{
+			"id":   p.ID,
+			"name": p.Name,
+			"uri":  p.URI(),
+			"tracks": map[string]any{
+				"href":  baseURL(r) + "/v1/playlists/" + p.ID + "/tracks",
+				"total": len(p.TrackIDs),
+			},
+		})
+	}
+
+	writeJSON(w, http.StatusOK, map[string]any{
+		"items":  items,
+		"limit":  limit,
+		"offset": offset,
+		"total":  total,
+		"next":   nilIfEmpty(nextURL(r, r.URL.Path, limit, offset, total)),
+	})
+}
+
+// handleAlbum returns an album's name; the fake knows no more about albums
+func (s *Server) handleAlbum(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	id := r.PathValue("id")
+	for _, t := range s.trackList {
+		if catalogID(t.Album) == id {
+			writeJSON(w, http.StatusOK, map[string]any{"id": id, "name": t.Album, "uri": "spotify:album:" + id})
+			return
+		}
+	}
+	writeError(w, http.StatusNotFound, "Non existing id", "")
+}
+
+// handleArtist returns an artist's name
+func (s *Server) handleArtist(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	id := r.PathValue("id")
+	for _, t := range s.trackList {
+		for _, a := range t.Artists {
+			if catalogID(a) == id {
+				writeJSON(w, http.StatusOK, map[string]any{"id": id, "name": a, "uri": "spotify:artist:" + id})
+				return
+			}
+		}
+	}
+	writeError(w, http.StatusNotFound, "Non existing id", "")
+}
+
+// handlePlaylist ignores the fields parameter and always returns the same summary
+func (s *Server) handlePlaylist(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	p, ok := s.findPlaylist(r.PathValue("id"))
+	if !ok {
+		writeError(w, http.StatusNotFound, "Not found.", "")
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]any{
+		"id":   p.ID,
+		"name": p.Name,
+		"uri":  p.URI(),
+		"tracks": map[string]any{
+			"href":  baseURL(r) + "/v1/playlists/" + p.ID + "/tracks",
+			"total": len(p.TrackIDs),
+		},
+	})
+}
+
+func (s *Server) handlePlaylistTracks(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	p, ok := s.findPlaylist(r.PathValue("id"))
+	if !ok {
+		writeError(w, http.StatusNotFound, "Not found.", "")
+		return
+	}
+
+	limit, offset := paging(r, 100, 100)
+	total := len(p.TrackIDs)
+
+	items := []map[string]any{}
+	for i := offset; i < total && i < offset+limit; i++ {
+		items = append(items, map[string]any{
+			"track": trackJSON(s.tracks[p.TrackIDs[i]]),
+		})
+	}
+
+	writeJSON(w, http.StatusOK, map[string]any{
+		"items":  items,
+		"limit":  limit,
+		"offset": offset,
+		"total":  total,
+		"next":   nilIfEmpty(nextURL(r, r.URL.Path, limit, offset, total)),
+	})
+}
+
+// handleSearch does a case-insensitive substring match on track name and artists
+func (s *Server) handleSearch(w http.ResponseWriter, r *http.Request) {
+	q := strings.ToLower(strings.TrimSpace(r.URL.Query().Get("q")))
+	if q == "" {
+		writeError(w, http.StatusBadRequest, "No search query", "")
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var matches []fakeTrack
+	for _, t := range s.trackList {
+		haystack := strings.ToLower(t.Name + " " + strings.Join(t.Artists, " "))
+		if strings.Contains(haystack, q) {
+			matches = append(matches, t)
+		}
+	}
+
+	limit, offset := paging(r, 20, 50)
+	items := []map[string]any{}
+	for i := offset; i < len(matches) && i < offset+limit; i++ {
+		items = append(items, trackJSON(matches[i]))
+	}
+
+	writeJSON(w, http.StatusOK, map[string]any{
+		"tracks": map[string]any{
+			"items":  items,
+			"limit":  limit,
+			"offset": offset,
+			"total":  len(matches),
+		},
+	})
+}
+
+// findPlaylist must be called with s.mu held
+func (s *Server) findPlaylist(id string) (fakePlaylist, bool) {
+	for _, p := range s.playlists {
+		if p.ID == id {
+			return p, true
+		}
+	}
+	return fakePlaylist{}, false
+}
+
+// nilIfEmpty makes paging links encode as null rather than "" like the real API
+func nilIfEmpty(s string) any {
+	if s == "" {
+		return nil
+	}
+	return s
+}
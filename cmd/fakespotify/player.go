@@ -0,0 +1,468 @@
+package fakespotify
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+type playRequest struct {
+	ContextURI *string   `json:"context_uri"`
+	Uris       *[]string `json:"uris"`
+	Offset     *struct {
+		Position *int    `json:"position"`
+		URI      *string `json:"uri"`
+	} `json:"offset"`
+	PositionMS *int `json:"position_ms"`
+}
+
+func (s *Server) handleCurrentlyPlaying(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	t, ok := s.currentTrack()
+	if !ok || s.player.activeDevice == "" {
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+
+	resp := map[string]any{
+		"is_playing":  s.player.isPlaying,
+		"progress_ms": s.progress(),
+		"item":        trackJSON(t),
+	}
+	if s.player.contextURI != "" {
+		resp["context"] = map[string]any{"uri": s.player.contextURI}
+	}
+	writeJSON(w, http.StatusOK, resp)
+}
+
+func (s *Server) handlePlay(w http.ResponseWriter, r *http.Request) {
+	var req playRequest
+	if r.Body != nil {
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil && !errors.Is(err, io.EOF) {
+			writeError(w, http.StatusBadRequest, "Malformed json", "")
+			return
+		}
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if !s.requireDevice(w, r) {
+		return
+	}
+
+	switch {
+	case req.ContextURI != nil:
+		queue, ok := s.resolveContext(*req.ContextURI)
+		if !ok {
+			writeError(w, http.StatusNotFound, "Context not found", "")
+			return
+		}
+		s.player.contextURI = *req.ContextURI
+		s.player.queue = queue
+		s.player.index = 0
+	case req.Uris != nil:
+		var queue []string
+		for _, uri := range *req.Uris {
+			id := strings.TrimPrefix(uri, "spotify:track:")
+			if _, ok := s.tracks[id]; !ok {
+				writeError(w, http.StatusBadRequest, "Invalid track uri: "+uri, "")
+				return
+			}
+			queue = append(queue, id)
+		}
+		s.player.contextURI = ""
+		s.player.queue = queue
+		s.player.index = 0
+	default:
+		// plain resume
+		if _, ok := s.currentTrack(); !ok {
+			writeError(w, http.StatusNotFound, "Nothing to resume", "")
+			return
+		}
+	}
+
+	if req.Offset != nil {
+		if req.Offset.Position != nil {
+			s.player.index = *req.Offset.Position
+		} else if req.Offset.URI != nil {
+			id := strings.TrimPrefix(*req.Offset.URI, "spotify:track:")
+			for i, q := range s.player.queue {
+				if q == id {
+					s.player.index = i
+				}
+			}
+		}
+		if s.player.index < 0 || s.player.index >= len(s.player.queue) {
+			s.player.index = 0
+		}
+	}
+
+	if req.ContextURI != nil || req.Uris != nil || req.Offset != nil {
+		// starting something new ends a queued track, but the rest of the queue stays
+		s.player.playingQueue = ""
+		s.setPosition(0)
+	} else {
+		s.setPosition(s.progress())
+	}
+	if req.PositionMS != nil {
+		s.setPosition(*req.PositionMS)
+	}
+	s.player.isPlaying = true
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (s *Server) handlePause(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if !s.requireDevice(w, r) {
+		return
+	}
+	s.setPosition(s.progress())
+	s.player.isPlaying = false
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (s *Server) handleNext(w http.ResponseWriter, r *http.Request) {
+	s.skip(w, r, 1)
+}
+
+func (s *Server) handlePrevious(w http.ResponseWriter, r *http.Request) {
+	s.skip(w, r, -1)
+}
+
+func (s *Server) skip(w http.ResponseWriter, r *http.Request, delta int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if !s.requireDevice(w, r) {
+		return
+	}
+	if len(s.player.queue) == 0 && len(s.player.userQueue) == 0 {
+		writeError(w, http.StatusNotFound, "Nothing is playing", "")
+		return
+	}
+
+	if delta > 0 {
+		s.advance()
+	} else if s.player.playingQueue != "" {
+		// back from a queued track to the context track it interrupted
+		s.player.playingQueue = ""
+	} else if n := len(s.player.queue); n > 0 {
+		s.player.index = ((s.player.index-1)%n + n) % n
+	}
+	s.setPosition(0)
+	s.player.isPlaying = true
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// advance must be called with s.mu held. It moves to the next queued track, or
+// back to the context after the one that was playing, wrapping around like a
+// repeating context.
+func (s *Server) advance() {
+	if len(s.player.userQueue) > 0 {
+		s.player.playingQueue = s.player.userQueue[0]
+		s.player.userQueue = s.player.userQueue[1:]
+		return
+	}
+	s.player.playingQueue = ""
+	if n := len(s.player.queue); n > 0 {
+		s.player.index = (s.player.index + 1) % n
+	}
+}
+
+// queueLimit caps the upcoming tracks GET /me/player/queue returns, as the real API does
+const queueLimit = 20
+
+func (s *Server) handleQueue(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	resp := map[string]any{"currently_playing": nil, "queue": []any{}}
+	t, ok := s.currentTrack()
+	if !ok || s.player.activeDevice == "" {
+		writeJSON(w, http.StatusOK, resp)
+		return
+	}
+	resp["currently_playing"] = trackJSON(t)
+
+	upcoming := append([]string(nil), s.player.userQueue...)
+	// the context resumes after the track a queued one interrupted
+	for i := s.player.index + 1; i < len(s.player.queue); i++ {
+		upcoming = append(upcoming, s.player.queue[i])
+	}
+	if len(upcoming) > queueLimit {
+		upcoming = upcoming[:queueLimit]
+	}
+
+	items := []any{}
+	for _, id := range upcoming {
+		items = append(items, trackJSON(s.tracks[id]))
+	}
+	resp["queue"] = items
+	writeJSON(w, http.StatusOK, resp)
+}
+
+func (s *Server) handleAddToQueue(w http.ResponseWriter, r *http.Request) {
+	uri := r.URL.Query().Get("uri")
+	id, ok := strings.CutPrefix(uri, "spotify:track:")
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, known := s.tracks[id]; !ok || !known {
+		writeError(w, http.StatusBadRequest, "Invalid track uri: "+uri, "")
+		return
+	}
+	if !s.requireDevice(w, r) {
+		return
+	}
+	s.player.userQueue = append(s.player.userQueue, id)
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// requireDevice must be called with s.mu held. A device_id parameter makes that
+// device the active one first. It writes the 404 the real API returns when no
+// device is active and reports whether the caller may continue.
+func (s *Server) requireDevice(w http.ResponseWriter, r *http.Request) bool {
+	if id := r.URL.Query().Get("device_id"); id != "" {
+		if _, ok := s.findDevice(id); !ok {
+			writeError(w, http.StatusNotFound, "Device not found", "")
+			return false
+		}
+		s.player.activeDevice = id
+	}
+	if s.player.activeDevice != "" {
+		return true
+	}
+	writeError(w, http.StatusNotFound, "Player command failed: No active device found", "NO_ACTIVE_DEVICE")
+	return false
+}
+
+// findDevice must be called with s.mu held
+func (s *Server) findDevice(id string) (*fakeDevice, bool) {
+	for i := range s.devices {
+		if s.devices[i].ID == id {
+			return &s.devices[i], true
+		}
+	}
+	return nil, false
+}
+
+func (s *Server) handleDevices(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	devices := []map[string]any{}
+	for _, d := range s.devices {
+		devices = append(devices, map[string]any{
+			"id":                 d.ID,
+			"name":               d.Name,
+			"type":               d.Type,
+			"volume_percent":     d.Volume,
+			"is_active":          d.ID == s.player.activeDevice,
+			"is_private_session": false,
+			"is_restricted":      false,
+			"supports_volume":    true,
+		})
+	}
+	writeJSON(w, http.StatusOK, map[string]any{"devices": devices})
+}
+
+func (s *Server) handleTransfer(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		DeviceIDs []string `json:"device_ids"`
+		Play      bool     `json:"play"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil || len(req.DeviceIDs) != 1 {
+		writeError(w, http.StatusBadRequest, "device_ids must contain exactly one device", "")
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.findDevice(req.DeviceIDs[0]); !ok {
+		writeError(w, http.StatusNotFound, "Device not found", "")
+		return
+	}
+	s.player.activeDevice = req.DeviceIDs[0]
+	if _, ok := s.currentTrack(); ok && req.Play {
+		s.setPosition(s.progress())
+		s.player.isPlaying = true
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (s *Server) handlePlayer(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	device, ok := s.findDevice(s.player.activeDevice)
+	if !ok {
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+
+	resp := map[string]any{
+		"device": map[string]any{
+			"id":             device.ID,
+			"name":           device.Name,
+			"type":           device.Type,
+			"volume_percent": device.Volume,
+			"is_active":      true,
+		},
+		"shuffle_state":          s.player.shuffle,
+		"repeat_state":           s.player.repeat,
+		"is_playing":             s.player.isPlaying,
+		"progress_ms":            nil,
+		"item":                   nil,
+		"context":                nil,
+		"currently_playing_type": "track",
+	}
+	if t, ok := s.currentTrack(); ok {
+		resp["progress_ms"] = s.progress()
+		resp["item"] = trackJSON(t)
+	}
+	if s.player.contextURI != "" {
+		// spotify:<type>:<id>
+		kind := strings.Split(s.player.contextURI, ":")[1]
+		resp["context"] = map[string]any{"type": kind, "uri": s.player.contextURI}
+	}
+	writeJSON(w, http.StatusOK, resp)
+}
+
+func (s *Server) handleSeek(w http.ResponseWriter, r *http.Request) {
+	position, err := strconv.Atoi(r.URL.Query().Get("position_ms"))
+	if err != nil || position < 0 {
+		writeError(w, http.StatusBadRequest, "Invalid position_ms", "")
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if !s.requireDevice(w, r) {
+		return
+	}
+	t, ok := s.currentTrack()
+	if !ok {
+		writeError(w, http.StatusNotFound, "Nothing is playing", "")
+		return
+	}
+	// Seeking past the end skips to the next track, like the real API
+	if position >= t.DurationMS {
+		s.advance()
+		position = 0
+	}
+	s.setPosition(position)
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (s *Server) handleVolume(w http.ResponseWriter, r *http.Request) {
+	volume, err := strconv.Atoi(r.URL.Query().Get("volume_percent"))
+	if err != nil || volume < 0 || volume > 100 {
+		writeError(w, http.StatusBadRequest, "Invalid volume_percent", "")
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if !s.requireDevice(w, r) {
+		return
+	}
+	device, _ := s.findDevice(s.player.activeDevice)
+	device.Volume = volume
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (s *Server) handleShuffle(w http.ResponseWriter, r *http.Request) {
+	state, err := strconv.ParseBool(r.URL.Query().Get("state"))
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "Invalid state", "")
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if !s.requireDevice(w, r) {
+		return
+	}
+	s.player.shuffle = state
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (s *Server) handleRepeat(w http.ResponseWriter, r *http.Request) {
+	state := r.URL.Query().Get("state")
+	if state != "off" && state != "track" && state != "context" {
+		writeError(w, http.StatusBadRequest, "Invalid state", "")
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if !s.requireDevice(w, r) {
+		return
+	}
+	s.player.repeat = state
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// progress must be called with s.mu held. It returns the position in the current
+// track, which advances while playing and stops at the end of the track.
+func (s *Server) progress() int {
+	position := s.player.positionMS
+	if s.player.isPlaying && !s.player.positionAt.IsZero() {
+		position += int(time.Since(s.player.positionAt).Milliseconds())
+	}
+	if t, ok := s.currentTrack(); ok && position > t.DurationMS {
+		position = t.DurationMS
+	}
+	return position
+}
+
+// setPosition must be called with s.mu held
+func (s *Server) setPosition(ms int) {
+	s.player.positionMS = ms
+	s.player.positionAt = time.Now()
+}
+
+// resolveContext returns the track IDs of a playlist, album or artist URI. It must be
+// called with s.mu held.
+func (s *Server) resolveContext(uri string) ([]string, bool) {
+	parts := strings.Split(uri, ":")
+	if len(parts) != 3 || parts[0] != "spotify" {
+		return nil, false
+	}
+	kind, id := parts[1], parts[2]
+
+	if kind == "playlist" {
+		p, ok := s.findPlaylist(id)
+		if !ok {
+			return nil, false
+		}
+		return append([]string(nil), p.TrackIDs...), true
+	}
+
+	var ids []string
+	for _, t := range s.trackList {
+		switch {
+		case kind == "album" && catalogID(t.Album) == id:
+			ids = append(ids, t.ID)
+		case kind == "artist" && slices.ContainsFunc(t.Artists, func(a string) bool { return catalogID(a) == id }):
+			ids = append(ids, t.ID)
+		}
+	}
+	return ids, len(ids) > 0
+}
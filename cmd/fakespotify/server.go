@@ -0,0 +1,305 @@
+// Package fakespotify is an in-memory stand-in for the parts of the Spotify Web API
+// and accounts service that Gitify talks to. Point SPOTIFY_API_BASE_URL at
+// <server>/v1 and SPOTIFY_ACCOUNTS_BASE_URL at <server> to use it.
+package fakespotify
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// DefaultAccessToken and DefaultRefreshToken are valid from the start so a
+// hand-written token.json works without going through the login flow.
+const (
+	DefaultAccessToken  = "fake-access-token"
+	DefaultRefreshToken = "fake-refresh-token"
+)
+
+// DefaultScope is granted to the default tokens and to logins that don't ask for scopes
+const DefaultScope = "user-read-private user-read-email user-library-read playlist-read-private user-read-playback-state user-modify-playback-state streaming"
+
+// Server holds all fake state. It is safe for concurrent use.
+type Server struct {
+	mu sync.Mutex
+
+	user      fakeUser
+	tracks    map[string]fakeTrack
+	trackList []fakeTrack // keeps search results in a stable order
+	playlists []fakePlaylist
+
+	// issued tokens and the space separated scopes they were granted
+	accessTokens  map[string]string
+	refreshTokens map[string]string
+	authCodes     map[string]authCode
+
+	devices []fakeDevice
+	player  playerState
+
+	// injected failures for the Web API, consumed one per request
+	failures   []int
+	retryAfter int
+
+	mux *http.ServeMux
+}
+
+// authCode is an authorization code waiting to be exchanged
+type authCode struct {
+	challenge string // PKCE code_challenge, "" without PKCE
+	scope     string
+}
+
+type playerState struct {
+	activeDevice string // device ID, "" when none is active
+	isPlaying    bool
+	contextURI   string
+	queue        []string // track IDs of the current context
+	index        int
+	userQueue    []string // track IDs added with POST /me/player/queue, played before the context resumes
+	playingQueue string   // ID of the queued track playing now, "" while the context plays
+	shuffle      bool
+	repeat       string // off, track or context
+
+	// position in the current track as of positionAt; it advances while playing
+	positionMS int
+	positionAt time.Time
+}
+
+// New returns a server seeded with a user, a few playlists and an active device.
+func New() *Server {
+	s := &Server{
+		user:          seedUser(),
+		tracks:        map[string]fakeTrack{},
+		playlists:     seedPlaylists(),
+		accessTokens:  map[string]string{DefaultAccessToken: DefaultScope},
+		refreshTokens: map[string]string{DefaultRefreshToken: DefaultScope},
+		authCodes:     map[string]authCode{},
+		devices:       seedDevices(),
+	}
+	s.player.activeDevice = s.devices[0].ID
+	s.player.repeat = "off"
+	s.trackList = seedTracks()
+	for _, t := range s.trackList {
+		s.tracks[t.ID] = t
+	}
+
+	mux := http.NewServeMux()
+
+	// accounts service
+	mux.HandleFunc("GET /authorize", s.handleAuthorize)
+	mux.HandleFunc("POST /api/token", s.handleToken)
+
+	// Web API, with the scope each endpoint requires
+	mux.HandleFunc("GET /v1/me", s.authed("", s.handleMe))
+	mux.HandleFunc("GET /v1/me/playlists", s.authed("playlist-read-private", s.handlePlaylists))
+	mux.HandleFunc("GET /v1/users/{id}/playlists", s.authed("playlist-read-private", s.handlePlaylists))
+	mux.HandleFunc("GET /v1/playlists/{id}", s.authed("", s.handlePlaylist))
+	mux.HandleFunc("GET /v1/playlists/{id}/tracks", s.authed("", s.handlePlaylistTracks))
+	mux.HandleFunc("GET /v1/albums/{id}", s.authed("", s.handleAlbum))
+	mux.HandleFunc("GET /v1/artists/{id}", s.authed("", s.handleArtist))
+	mux.HandleFunc("GET /v1/search", s.authed("", s.handleSearch))
+	mux.HandleFunc("GET /v1/me/player/currently-playing", s.authed("user-read-playback-state", s.handleCurrentlyPlaying))
+	mux.HandleFunc("GET /v1/me/player", s.authed("user-read-playback-state", s.handlePlayer))
+	mux.HandleFunc("GET /v1/me/player/devices", s.authed("user-read-playback-state", s.handleDevices))
+	mux.HandleFunc("PUT /v1/me/player", s.authed("user-modify-playback-state", s.handleTransfer))
+	mux.HandleFunc("PUT /v1/me/player/seek", s.authed("user-modify-playback-state", s.handleSeek))
+	mux.HandleFunc("PUT /v1/me/player/volume", s.authed("user-modify-playback-state", s.handleVolume))
+	mux.HandleFunc("PUT /v1/me/player/shuffle", s.authed("user-modify-playback-state", s.handleShuffle))
+	mux.HandleFunc("PUT /v1/me/player/repeat", s.authed("user-modify-playback-state", s.handleRepeat))
+	mux.HandleFunc("GET /v1/me/player/queue", s.authed("user-read-playback-state", s.handleQueue))
+	mux.HandleFunc("POST /v1/me/player/queue", s.authed("user-modify-playback-state", s.handleAddToQueue))
+	mux.HandleFunc("PUT /v1/me/player/play", s.authed("user-modify-playback-state", s.handlePlay))
+	mux.HandleFunc("PUT /v1/me/player/pause", s.authed("user-modify-playback-state", s.handlePause))
+	mux.HandleFunc("POST /v1/me/player/next", s.authed("user-modify-playback-state", s.handleNext))
+	mux.HandleFunc("POST /v1/me/player/previous", s.authed("user-modify-playback-state", s.handlePrevious))
+
+	s.mux = mux
+	return s
+}
+
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mux.ServeHTTP(w, r)
+}
+
+// SetDeviceActive toggles whether player commands find an active device: the first
+// seeded one, or none. With no active device they fail with 404 NO_ACTIVE_DEVICE
+// like the real API, unless they name a device with device_id.
+func (s *Server) SetDeviceActive(active bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.player.activeDevice = ""
+	if active {
+		s.player.activeDevice = s.devices[0].ID
+	} else {
+		s.player.isPlaying = false
+	}
+}
+
+// ExpireAccessTokens invalidates every issued access token, so the next API
+// call gets a 401 and the client has to refresh.
+func (s *Server) ExpireAccessTokens() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.accessTokens = map[string]string{}
+}
+
+// InjectFailures makes the next count Web API requests fail with status. For 429
+// responses a Retry-After of retryAfterSecs is sent.
+func (s *Server) InjectFailures(status, count, retryAfterSecs int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for i := 0; i < count; i++ {
+		s.failures = append(s.failures, status)
+	}
+	s.retryAfter = retryAfterSecs
+}
+
+// NowPlaying reports the URI of the current track and whether it is playing.
+func (s *Server) NowPlaying() (uri string, playing bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	t, ok := s.currentTrack()
+	if !ok {
+		return "", false
+	}
+	return t.URI(), s.player.isPlaying
+}
+
+// ---------------- helpers ----------------
+
+// currentTrack must be called with s.mu held
+func (s *Server) currentTrack() (fakeTrack, bool) {
+	p := s.player
+	if p.playingQueue != "" {
+		t, ok := s.tracks[p.playingQueue]
+		return t, ok
+	}
+	if p.index < 0 || p.index >= len(p.queue) {
+		return fakeTrack{}, false
+	}
+	t, ok := s.tracks[p.queue[p.index]]
+	return t, ok
+}
+
+// authed rejects requests without a valid access token, or whose token was not
+// granted scope (when not empty), before calling next.
+func (s *Server) authed(scope string, next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		token := strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ")
+
+		s.mu.Lock()
+		granted, ok := s.accessTokens[token]
+		failure := 0
+		if len(s.failures) > 0 {
+			failure, s.failures = s.failures[0], s.failures[1:]
+		}
+		retryAfter := s.retryAfter
+		s.mu.Unlock()
+
+		if failure != 0 {
+			if failure == http.StatusTooManyRequests {
+				w.Header().Set("Retry-After", strconv.Itoa(retryAfter))
+			}
+			writeError(w, failure, http.StatusText(failure), "")
+			return
+		}
+		if !ok {
+			writeError(w, http.StatusUnauthorized, "The access token expired", "")
+			return
+		}
+		if scope != "" && !hasScope(granted, scope) {
+			writeError(w, http.StatusForbidden, "Insufficient client scope", "")
+			return
+		}
+		next(w, r)
+	}
+}
+
+func hasScope(granted, scope string) bool {
+	for _, g := range strings.Fields(granted) {
+		if g == scope {
+			return true
+		}
+	}
+	return false
+}
+
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
+
+// writeError mirrors Spotify's error body: {"error":{"status":..,"message":..,"reason":..}}
+func writeError(w http.ResponseWriter, status int, message, reason string) {
+	body := map[string]any{
+		"status":  status,
+		"message": message,
+	}
+	if reason != "" {
+		body["reason"] = reason
+	}
+	writeJSON(w, status, map[string]any{"error": body})
+}
+
+// baseURL rebuilds the server origin from the request so paging links work
+// whatever address the server was started on.
+func baseURL(r *http.Request) string {
+	scheme := "http"
+	if r.TLS != nil {
+		scheme = "https"
+	}
+	return scheme + "://" + r.Host
+}
+
+func randomToken(prefix string) string {
+	b := make([]byte, 12)
+	rand.Read(b)
+	return prefix + hex.EncodeToString(b)
+}
+
+// paging reads limit/offset query params, clamping limit to [1, max].
+func paging(r *http.Request, def, max int) (limit, offset int) {
+	limit = def
+	if v, err := strconv.Atoi(r.URL.Query().Get("limit")); err == nil && v > 0 {
+		limit = v
+	}
+	if limit > max {
+		limit = max
+	}
+	if v, err := strconv.Atoi(r.URL.Query().Get("offset")); err == nil && v > 0 {
+		offset = v
+	}
+	return limit, offset
+}
+
+// nextURL returns the link to the following page, or "" on the last page.
+func nextURL(r *http.Request, path string, limit, offset, total int) string {
+	if offset+limit >= total {
+		return ""
+	}
+	return baseURL(r) + path + "?offset=" + strconv.Itoa(offset+limit) + "&limit=" + strconv.Itoa(limit)
+}
+
+func trackJSON(t fakeTrack) map[string]any {
+	artists := make([]map[string]any, len(t.Artists))
+	for i, a := range t.Artists {
+		artists[i] = map[string]any{"id": catalogID(a), "name": a, "uri": "spotify:artist:" + catalogID(a)}
+	}
+	return map[string]any{
+		"id":          t.ID,
+		"name":        t.Name,
+		"uri":         t.URI(),
+		"duration_ms": t.DurationMS,
+		"artists":     artists,
+		"album":       map[string]any{"id": catalogID(t.Album), "name": t.Album, "uri": "spotify:album:" + catalogID(t.Album)},
+		"external_urls": map[string]any{
+			"spotify": "https://open.spotify.com/track/" + t.ID,
+		},
+	}
+}
@@ -0,0 +1,102 @@
+package fakespotify
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// request sends an authorized request to the fake and decodes a JSON reply into v, if given
+func request(t *testing.T, srv *httptest.Server, method, path, body string, v any) int {
+	t.Helper()
+	req, err := http.NewRequest(method, srv.URL+path, strings.NewReader(body))
+	if err != nil {
+		t.Fatal(err)
+	}
+	req.Header.Set("Authorization", "Bearer "+DefaultAccessToken)
+	resp, err := srv.Client().Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	if v != nil && resp.StatusCode == http.StatusOK {
+		if err := json.NewDecoder(resp.Body).Decode(v); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return resp.StatusCode
+}
+
+func TestPlayContexts(t *testing.T) {
+	tests := []struct {
+		uri        string
+		status     int
+		kind       string
+		firstTrack string
+	}{
+		{"spotify:playlist:playlist02", http.StatusNoContent, "playlist", "spotify:track:track03"},
+		{"spotify:album:FutureNostalgia", http.StatusNoContent, "album", "spotify:track:track02"},
+		{"spotify:artist:DuaLipa", http.StatusNoContent, "artist", "spotify:track:track02"},
+		{"spotify:artist:DaBaby", http.StatusNoContent, "artist", "spotify:track:track02"},
+		{"spotify:album:NoSuchAlbum", http.StatusNotFound, "", ""},
+		{"spotify:show:playlist02", http.StatusNotFound, "", ""},
+		{"spotify:playlist", http.StatusNotFound, "", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.uri, func(t *testing.T) {
+			srv := httptest.NewServer(New())
+			defer srv.Close()
+
+			status := request(t, srv, http.MethodPut, "/v1/me/player/play", `{"context_uri":"`+tt.uri+`"}`, nil)
+			if status != tt.status {
+				t.Fatalf("play returned %d, want %d", status, tt.status)
+			}
+			if tt.status != http.StatusNoContent {
+				return
+			}
+
+			var player struct {
+				Context struct {
+					Type string `json:"type"`
+					URI  string `json:"uri"`
+				} `json:"context"`
+				Item struct {
+					URI string `json:"uri"`
+				} `json:"item"`
+			}
+			request(t, srv, http.MethodGet, "/v1/me/player", "", &player)
+			if player.Context.Type != tt.kind || player.Context.URI != tt.uri {
+				t.Errorf("context = %s %s, want %s %s", player.Context.Type, player.Context.URI, tt.kind, tt.uri)
+			}
+			if player.Item.URI != tt.firstTrack {
+				t.Errorf("playing %s, want %s", player.Item.URI, tt.firstTrack)
+			}
+		})
+	}
+}
+
+func TestAlbumAndArtist(t *testing.T) {
+	srv := httptest.NewServer(New())
+	defer srv.Close()
+
+	tests := []struct {
+		path   string
+		status int
+		name   string
+	}{
+		{"/v1/albums/AfterHours", http.StatusOK, "After Hours"},
+		{"/v1/albums/HurryUpWereDreaming", http.StatusOK, "Hurry Up, We're Dreaming"},
+		{"/v1/artists/aha", http.StatusOK, "a-ha"},
+		{"/v1/artists/Nobody", http.StatusNotFound, ""},
+	}
+	for _, tt := range tests {
+		var v struct {
+			Name string `json:"name"`
+		}
+		if status := request(t, srv, http.MethodGet, tt.path, "", &v); status != tt.status || v.Name != tt.name {
+			t.Errorf("GET %s = %d %q, want %d %q", tt.path, status, v.Name, tt.status, tt.name)
+		}
+	}
+}
@@ -1,11 +1,16 @@
 package cmd
 
 import (
+	"bufio"
 	"context"
 	"fmt"
+	"net"
 	"net/http"
+	"net/url"
 	"os/exec"
 	"runtime"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/adi-253/Gitify/cmd/utils"
@@ -13,82 +18,235 @@ import (
 )
 
 func openbrowser(url string) {
-    var err error
-    switch runtime.GOOS {
-    case "linux":
-        err = exec.Command("xdg-open", url).Start()
-    case "windows":
-        err = exec.Command("rundll32", "url.dll,FileProtocolHandler", url).Start()
-    case "darwin":
-        err = exec.Command("open", url).Start()
-    }
-    if err != nil {
-        fmt.Println("Please open the following URL manually:", url)
-    }
+	var err error
+	switch runtime.GOOS {
+	case "linux":
+		err = exec.Command("xdg-open", url).Start()
+	case "windows":
+		err = exec.Command("rundll32", "url.dll,FileProtocolHandler", url).Start()
+	case "darwin":
+		err = exec.Command("open", url).Start()
+	}
+	if err != nil {
+		fmt.Println("Please open the following URL manually:", url)
+	}
 }
 
 var loginCmd = &cobra.Command{
-	Use: "login",
+	Use:   "login",
 	Short: "For logging into spotify",
-	Run: func(cmd *cobra.Command, args []string) {
-		// Create a channel to signal when login is complete
-		done := make(chan bool)
-
-		// Create a new ServeMux to avoid conflicts with global handlers
-		mux := http.NewServeMux()
-		
-		// Create server with context for graceful shutdown
-		server := &http.Server{
-			Addr:    ":8080",
-			Handler: mux,
-		}
+	Long: `Login to Spotify through your browser.
+
+By default a local server receives Spotify's redirect on the port of the redirect URL
+(REDIRECT_URL, default http://localhost:8080/callback). Use --port or --redirect when
+8080 is taken; the redirect URL must also be registered for your Spotify app.
+
+On a remote machine (e.g. over SSH) use --no-browser: Gitify prints the authorize URL,
+you open it on any device, then paste back the URL you were redirected to (the page
+itself may fail to load, that's fine) or just the code from it.`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return runLogin(cmd, loginOptionsFromFlags(cmd))
+	},
+}
+
+// loginOptions are the knobs of the login flow, normally set from the login flags
+type loginOptions struct {
+	timeout   time.Duration
+	noBrowser bool
+	port      int
+	redirect  string
+	scopes    []string // requested on top of utils.DefaultScopes
+}
+
+func defaultLoginOptions() loginOptions {
+	return loginOptions{timeout: 5 * time.Minute}
+}
+
+// loginOptionsFromFlags reads the flags added by addLoginFlags
+func loginOptionsFromFlags(cmd *cobra.Command) loginOptions {
+	opts := defaultLoginOptions()
+	opts.timeout, _ = cmd.Flags().GetDuration("timeout")
+	opts.noBrowser, _ = cmd.Flags().GetBool("no-browser")
+	opts.port, _ = cmd.Flags().GetInt("port")
+	opts.redirect, _ = cmd.Flags().GetString("redirect")
+	opts.scopes, _ = cmd.Flags().GetStringSlice("scope")
+	return opts
+}
+
+// runLogin logs the active account in. Scopes the account was already granted are
+// requested again, so logging in never loses permissions.
+func runLogin(cmd *cobra.Command, opts loginOptions) error {
+	redirectURL, err := resolveRedirect(opts.redirect, opts.port)
+	if err != nil {
+		return err
+	}
+	utils.RedirectUrl = redirectURL.String()
+
+	if token, err := utils.StoredToken(); err == nil {
+		utils.AddLoginScopes(token.Scopes()...)
+	}
+	utils.AddLoginScopes(opts.scopes...)
+
+	if opts.noBrowser {
+		err = loginHeadless(cmd)
+	} else {
+		err = loginWithCallbackServer(cmd, redirectURL, opts.timeout)
+	}
+	if err != nil {
+		return fmt.Errorf("login failed: %v", err)
+	}
+	location, _ := utils.CredentialsLocation()
+	fmt.Println("Login completed. Access token saved to", location)
+	dropSharedClient()
+
+	//Also run storing profile info
+	if err := getUserInfo(cmd.Context()); err != nil {
+		return fmt.Errorf("couldn't fetch profile info: %v", err)
+	}
+	fmt.Println("Successfully stored user profile")
+	return nil
+}
+
+func addLoginFlags(cmd *cobra.Command) {
+	cmd.Flags().Duration("timeout", 5*time.Minute, "how long to wait for the browser login to finish")
+	cmd.Flags().Bool("no-browser", false, "print the authorize URL and paste the redirect back instead of running a local server")
+	cmd.Flags().Int("port", 0, "port for the local callback server (also changes the redirect URL's port)")
+	cmd.Flags().String("redirect", "", "redirect URL registered for your Spotify app (default from REDIRECT_URL)")
+	cmd.Flags().StringSlice("scope", nil, "extra OAuth scopes to request, e.g. playlist-modify-private (repeatable or comma separated)")
+}
+
+// resolveRedirect applies --redirect and --port to the configured redirect URL.
+// --port alone moves the default redirect to that port.
+func resolveRedirect(redirect string, port int) (*url.URL, error) {
+	if redirect == "" {
+		redirect = utils.RedirectUrl
+	}
+	u, err := url.Parse(redirect)
+	if err != nil || u.Scheme == "" || u.Host == "" {
+		return nil, fmt.Errorf("invalid redirect URL %q", redirect)
+	}
+	if port != 0 {
+		u.Host = net.JoinHostPort(u.Hostname(), strconv.Itoa(port))
+	}
+	return u, nil
+}
+
+// loginWithCallbackServer opens the browser and waits for Spotify to redirect back to
+// a local server listening on the redirect URL's host, port and path.
+func loginWithCallbackServer(cmd *cobra.Command, redirectURL *url.URL, timeout time.Duration) error {
+	port := redirectURL.Port()
+	if port == "" {
+		port = "80"
+	}
+	// Listen only where the browser is sent, not on every interface
+	host := redirectURL.Hostname()
+	if host == "" {
+		host = "127.0.0.1"
+	}
+	addr := net.JoinHostPort(host, port)
+	// Match the callback path exactly; "/" or "/cb/" alone would match every path below
+	callbackPattern := redirectURL.Path
+	if callbackPattern == "" {
+		callbackPattern = "/"
+	}
+	if strings.HasSuffix(callbackPattern, "/") {
+		callbackPattern += "{$}"
+	}
+	loginURL := "http://" + addr + "/login"
 
-		// Setup handlers with the done channel
-		mux.HandleFunc("/login", utils.LoginHandler)
-		mux.HandleFunc("/callback", func(w http.ResponseWriter, r *http.Request) {
-			utils.HandleCallback(w, r)
-			// Signal that login is complete
-			go func() {
-				time.Sleep(1 * time.Second) // Give time for response to be sent
-				done <- true
-			}()
-		})
-
-		// Start server in goroutine
-		go func() {
-			fmt.Println("Starting local server at http://localhost:8080/login ...")
-			if err := server.ListenAndServe(); err != nil && err != http.ErrServerClosed {
-				fmt.Println("Server failed:", err)
-			}
-		}()
-
-		// Open browser after server starts
-		go func() {
-			time.Sleep(1 * time.Second)
-			openbrowser("http://localhost:8080/login")
-		}()
-
-		// Wait for login completion
-		<-done
-
-		// Shutdown server gracefully
-		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
-		defer cancel()
-		
-		fmt.Println("Login completed. Shutting down server...")
-		if err := server.Shutdown(ctx); err != nil {
-			fmt.Printf("Server shutdown error: %v\n", err)
+	// Receives the outcome of the callback (or a server failure); only the first one counts
+	done := make(chan error, 1)
+	finish := func(err error) {
+		select {
+		case done <- err:
+		default:
 		}
+	}
+
+	// Create a new ServeMux to avoid conflicts with global handlers
+	mux := http.NewServeMux()
+
+	// Create server with context for graceful shutdown
+	server := &http.Server{
+		Addr:              addr,
+		Handler:           mux,
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+
+	mux.HandleFunc("/login", utils.LoginHandler)
+	mux.HandleFunc(callbackPattern, utils.NewCallbackHandler(finish))
 
-		//Also run storing profile info
-		err:=getUserInfo()
-		if err!=nil{
-			fmt.Print("Couldnt fetch profile info")
+	// Start server in goroutine
+	go func() {
+		fmt.Printf("Starting local server at %s ...\n", loginURL)
+		if err := server.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+			finish(fmt.Errorf("login server failed: %v", err))
 		}
-		fmt.Printf("Successfuly stored user profile")
-	},
+	}()
+
+	// Open browser after server starts
+	go func() {
+		time.Sleep(1 * time.Second)
+		openbrowser(loginURL)
+	}()
+
+	// Wait for the callback, the timeout or Ctrl+C
+	var loginErr error
+	select {
+	case loginErr = <-done:
+	case <-time.After(timeout):
+		loginErr = fmt.Errorf("timed out after %s waiting for the Spotify login to finish", timeout)
+	case <-cmd.Context().Done():
+		loginErr = cmd.Context().Err()
+	}
+
+	// Shutdown server gracefully
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	fmt.Println("Shutting down login server...")
+	if err := server.Shutdown(ctx); err != nil {
+		fmt.Printf("Server shutdown error: %v\n", err)
+	}
+
+	return loginErr
 }
 
-func init(){
+// loginHeadless prints the authorize URL and reads the redirect URL (or code) from stdin
+func loginHeadless(cmd *cobra.Command) error {
+	authURL, err := utils.AuthorizeURL()
+	if err != nil {
+		return err
+	}
+	parsed, _ := url.Parse(authURL)
+	state := parsed.Query().Get("state")
+
+	fmt.Println("Open this URL in a browser on any device and approve access:")
+	fmt.Println()
+	fmt.Println("  " + authURL)
+	fmt.Println()
+	fmt.Println("You will be redirected to " + utils.RedirectUrl + "; the page may not load.")
+	fmt.Print("Paste the full URL from the address bar (or just the code): ")
+
+	line, err := bufio.NewReader(cmd.InOrStdin()).ReadString('\n')
+	if err != nil && line == "" {
+		return fmt.Errorf("could not read the redirect URL: %v", err)
+	}
+
+	code, pastedState, err := utils.ParseRedirect(line)
+	if err != nil {
+		return err
+	}
+	// A bare code carries no state; it can only belong to the login we just started
+	if pastedState == "" {
+		pastedState = state
+	}
+
+	return utils.CompleteLogin(cmd.Context(), code, pastedState)
+}
+
+func init() {
+	addLoginFlags(loginCmd)
+
 	spotifyCmd.AddCommand(loginCmd)
-}
\ No newline at end of file
+}
@@ -0,0 +1,232 @@
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/adi-253/Gitify/cmd/utils"
+	"github.com/spf13/cobra"
+	"golang.org/x/term"
+)
+
+// progressBarWidth is the number of cells in the now command's progress bar
+const progressBarWidth = 30
+
+// ContextName looks up the name of the playlist, album, artist or show playback comes
+// from. It returns "" for loose tracks, or when Spotify won't say, e.g. for someone
+// else's private playlist.
+func ContextName(ctx context.Context, client *utils.SpotifyClient, info *PlaybackInfo) string {
+	// spotify:playlist:<id>, or spotify:user:<user>:playlist:<id> for older playlists
+	id := info.ContextURI[strings.LastIndex(info.ContextURI, ":")+1:]
+
+	var path string
+	switch info.ContextType {
+	case "album":
+		// The playing track is on it, which saves a request
+		return info.Album
+	case "collection":
+		return "Liked Songs"
+	case "playlist":
+		path = "/playlists/" + url.PathEscape(id) + "?fields=name"
+	case "artist", "show":
+		path = "/" + info.ContextType + "s/" + url.PathEscape(id)
+	default:
+		return ""
+	}
+
+	resp, err := client.GetContext(ctx, client.APIURL(path))
+	if err != nil {
+		return ""
+	}
+	defer resp.Body.Close()
+	if utils.CheckResponse(resp) != nil {
+		return ""
+	}
+
+	var result struct {
+		Name string `json:"name"`
+	}
+	if json.NewDecoder(resp.Body).Decode(&result) != nil {
+		return ""
+	}
+	return result.Name
+}
+
+// parseFormat parses a --format template for a nowRecord
+func parseFormat(format string) (*template.Template, error) {
+	tmpl, err := template.New("format").Parse(format)
+	if err != nil {
+		return nil, fmt.Errorf("invalid --format: %v", err)
+	}
+	return tmpl, nil
+}
+
+// progressBar draws how far through a track playback is
+func progressBar(progressMS, durationMS, width int) string {
+	filled := 0
+	if durationMS > 0 {
+		filled = min(progressMS*width/durationMS, width)
+	}
+	return strings.Repeat("━", filled) + "●" + strings.Repeat("─", width-filled)
+}
+
+// printNow writes the playback state as a few lines of text
+func printNow(w io.Writer, r nowRecord) {
+	if r.State == "stopped" {
+		fmt.Fprintln(w, "Nothing is playing")
+		return
+	}
+
+	icon := "⏸"
+	if r.Playing() {
+		icon = "▶"
+	}
+	fmt.Fprintf(w, "%s %s\n", icon, r.Track)
+	if r.Album != "" {
+		fmt.Fprintf(w, "  %s · %s\n", r.Artist(), r.Album)
+	} else {
+		fmt.Fprintf(w, "  %s\n", r.Artist())
+	}
+	fmt.Fprintf(w, "  %s %s %s\n", r.Progress, progressBar(r.ProgressMS, r.DurationMS, progressBarWidth), r.Duration)
+
+	settings := []string{r.Device}
+	if r.Volume >= 0 {
+		settings = append(settings, fmt.Sprintf("🔊 %d%%", r.Volume))
+	}
+	shuffle := "off"
+	if r.Shuffle {
+		shuffle = "on"
+	}
+	settings = append(settings, "shuffle "+shuffle, "repeat "+r.Repeat)
+	fmt.Fprintf(w, "  %s\n", strings.Join(settings, " · "))
+
+	if r.ContextName != "" {
+		fmt.Fprintf(w, "  From %s %s\n", r.Context, r.ContextName)
+	}
+}
+
+var nowCmd = &cobra.Command{
+	Use:   "now",
+	Short: "Show what is playing",
+	Long: `Show the playing track with its artists, album and progress, the device, the
+shuffle and repeat settings, and the playlist or album it is playing from.
+
+--watch keeps running and redraws whenever that changes, checking every
+poll_interval. --format prints a Go template instead, one line per change with
+--watch. Templates see the fields of --output json in Go's spelling: .State
+(playing, paused or stopped), .Track, .Artist, .Artists, .Album, .URI, .Progress,
+.Duration, .ProgressMS, .DurationMS, .Device, .Volume, .Shuffle, .Repeat, .Context,
+.ContextURI, .ContextName, and .Playing:
+
+  gitify spotify now --format '{{.Artist}} - {{.Track}} [{{.Progress}}/{{.Duration}}]'
+  gitify spotify now --watch --format '{{if .Playing}}▶{{else}}⏸{{end}} {{.Track}}'`,
+	Args:        cobra.NoArgs,
+	Annotations: needsScopes("user-read-playback-state"),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		watch, _ := cmd.Flags().GetBool("watch")
+		format, _ := cmd.Flags().GetString("format")
+
+		var tmpl *template.Template
+		if format != "" {
+			if structuredOutput() {
+				return fmt.Errorf("--format and --output can't be used together")
+			}
+			var err error
+			if tmpl, err = parseFormat(format); err != nil {
+				return err
+			}
+		}
+
+		client, err := spotifyClient()
+		if err != nil {
+			return err
+		}
+
+		// Context names only change with the context, so look each one up once
+		names := map[string]string{}
+		frame := func() (string, error) {
+			info, err := GetCurrentPlayback(cmd.Context(), client)
+			if err != nil {
+				return "", errors.New(describeError(err))
+			}
+			name, ok := names[info.ContextURI]
+			if !ok && info.ContextURI != "" {
+				name = ContextName(cmd.Context(), client, info)
+				names[info.ContextURI] = name
+			}
+			record := newNowRecord(info, name)
+
+			var buf bytes.Buffer
+			if tmpl != nil {
+				if err := tmpl.Execute(&buf, record); err != nil {
+					return "", fmt.Errorf("--format: %v", err)
+				}
+				buf.WriteString("\n")
+			} else if err := renderTo(&buf, record, func(w io.Writer) { printNow(w, record) }); err != nil {
+				return "", err
+			}
+			return buf.String(), nil
+		}
+
+		if !watch {
+			out, err := frame()
+			if err != nil {
+				return err
+			}
+			fmt.Fprint(cmd.OutOrStdout(), out)
+			return nil
+		}
+		return watchFrames(cmd, frame, tmpl == nil && !structuredOutput())
+	},
+}
+
+// watchFrames prints frame every poll_interval until interrupted, skipping frames
+// that didn't change. Text for a terminal replaces the previous frame, anything else
+// is appended so it can be piped. Errors are shown in place of the frame, in case
+// they pass.
+func watchFrames(cmd *cobra.Command, frame func() (string, error), redraw bool) error {
+	w := cmd.OutOrStdout()
+	if f, ok := w.(*os.File); !ok || !term.IsTerminal(int(f.Fd())) {
+		redraw = false
+	}
+
+	last := ""
+	for {
+		out, err := frame()
+		if err != nil {
+			if cmd.Context().Err() != nil {
+				return nil
+			}
+			out = "⚠ " + err.Error() + "\n"
+		}
+		if out != last {
+			if redraw {
+				// Home the cursor and clear the screen
+				fmt.Fprint(w, "\x1b[H\x1b[2J")
+			}
+			fmt.Fprint(w, out)
+			last = out
+		}
+
+		select {
+		case <-cmd.Context().Done():
+			return nil
+		case <-time.After(utils.PollInterval):
+		}
+	}
+}
+
+func init() {
+	nowCmd.Flags().BoolP("watch", "w", false, "keep running and redraw when playback changes")
+	nowCmd.Flags().StringP("format", "f", "", "print a Go template instead, e.g. '{{.Artist}} - {{.Track}}'")
+	spotifyCmd.AddCommand(nowCmd)
+}
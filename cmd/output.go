@@ -0,0 +1,356 @@
+package cmd
+
+import (
+	"encoding"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"reflect"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/spf13/cobra"
+	"golang.org/x/term"
+	"gopkg.in/yaml.v3"
+)
+
+// Formats accepted by --output. Without one, commands print their usual text.
+const (
+	outputJSON  = "json"
+	outputYAML  = "yaml"
+	outputTable = "table"
+	outputTSV   = "tsv"
+)
+
+var outputFormats = []string{outputJSON, outputYAML, outputTable, outputTSV}
+
+// outputFormat is set from the global --output flag
+var outputFormat string
+
+func validateOutputFormat() error {
+	if outputFormat == "" {
+		return nil
+	}
+	for _, f := range outputFormats {
+		if outputFormat == f {
+			return nil
+		}
+	}
+	return fmt.Errorf("invalid --output %q: must be one of %s", outputFormat, strings.Join(outputFormats, ", "))
+}
+
+// structuredOutput reports whether --output asked for records instead of text
+func structuredOutput() bool {
+	return outputFormat != ""
+}
+
+// stdinIsTerminal reports whether someone is there to answer prompts
+func stdinIsTerminal() bool {
+	return term.IsTerminal(int(os.Stdin.Fd()))
+}
+
+// interactive reports whether commands may prompt: stdin is a terminal and --output
+// did not ask for records
+func interactive() bool {
+	return !structuredOutput() && stdinIsTerminal()
+}
+
+// render writes records, a struct or a slice of structs, in the --output format. The
+// json tags name the fields; tables and TSV get one column per field, in order. Without
+// --output, human prints the command's usual text instead.
+func render(cmd *cobra.Command, records any, human func(w io.Writer)) error {
+	return renderTo(cmd.OutOrStdout(), records, human)
+}
+
+// renderTo is render writing to w, for output built up before it is printed
+func renderTo(w io.Writer, records any, human func(w io.Writer)) error {
+	switch outputFormat {
+	case "":
+		human(w)
+		return nil
+	case outputJSON:
+		return writeJSON(w, records)
+	case outputYAML:
+		enc := yaml.NewEncoder(w)
+		enc.SetIndent(2)
+		if err := enc.Encode(toYAML(records)); err != nil {
+			return err
+		}
+		return enc.Close()
+	case outputTable:
+		tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+		writeRows(tw, records, joinCells)
+		return tw.Flush()
+	case outputTSV:
+		writeRows(w, records, joinCells)
+		return nil
+	default:
+		return validateOutputFormat()
+	}
+}
+
+// writeJSON prints v as indented JSON
+func writeJSON(w io.Writer, v any) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(v)
+}
+
+// toYAML converts records to YAML keyed by their json tags, nested structs included,
+// so both formats agree on names
+func toYAML(records any) *yaml.Node {
+	return yamlNode(reflect.ValueOf(records))
+}
+
+func yamlNode(v reflect.Value) *yaml.Node {
+	node := &yaml.Node{}
+	switch {
+	case !v.IsValid():
+		_ = node.Encode(nil)
+	case v.Kind() == reflect.Pointer || v.Kind() == reflect.Interface:
+		if v.IsNil() {
+			_ = node.Encode(nil)
+			break
+		}
+		return yamlNode(v.Elem())
+	case v.Kind() == reflect.Slice:
+		node.Kind = yaml.SequenceNode
+		node.Tag = "!!seq"
+		for i := 0; i < v.Len(); i++ {
+			node.Content = append(node.Content, yamlNode(v.Index(i)))
+		}
+	case v.Kind() == reflect.Struct && !v.Type().Implements(textMarshaler):
+		node.Kind = yaml.MappingNode
+		node.Tag = "!!map"
+		for _, f := range recordFields(v.Type()) {
+			field := v.Field(f.index)
+			if f.omitEmpty && field.IsZero() {
+				continue
+			}
+			node.Content = append(node.Content, &yaml.Node{Kind: yaml.ScalarNode, Value: f.name}, yamlNode(field))
+		}
+	default:
+		_ = node.Encode(v.Interface())
+	}
+	return node
+}
+
+// textMarshaler types, time.Time among them, print as the text they marshal to
+var textMarshaler = reflect.TypeFor[encoding.TextMarshaler]()
+
+// writeRows prints a header, then one line per record, using join to build each line
+func writeRows(w io.Writer, records any, join func(cells []string) string) {
+	v := reflect.ValueOf(records)
+	if v.Kind() != reflect.Slice {
+		v = reflect.Append(reflect.MakeSlice(reflect.SliceOf(v.Type()), 0, 1), v)
+	}
+	fields := recordFields(v.Type().Elem())
+
+	header := make([]string, len(fields))
+	for i, f := range fields {
+		header[i] = strings.ToUpper(f.name)
+	}
+	fmt.Fprintln(w, join(header))
+
+	for i := 0; i < v.Len(); i++ {
+		row := make([]string, len(fields))
+		for j, f := range fields {
+			// Leave out what JSON would, rather than print zeros
+			if field := v.Index(i).Field(f.index); !f.omitEmpty || !field.IsZero() {
+				row[j] = cellText(field)
+			}
+		}
+		fmt.Fprintln(w, join(row))
+	}
+}
+
+// joinCells joins cells with tabs, flattening tabs and newlines inside them to spaces
+// so each record stays one row
+func joinCells(cells []string) string {
+	flatten := strings.NewReplacer("\t", " ", "\r", " ", "\n", " ")
+	for i, c := range cells {
+		cells[i] = flatten.Replace(c)
+	}
+	return strings.Join(cells, "\t")
+}
+
+type recordField struct {
+	name      string
+	index     int
+	omitEmpty bool
+}
+
+func recordFields(t reflect.Type) []recordField {
+	var fields []recordField
+	for i := 0; i < t.NumField(); i++ {
+		tag := t.Field(i).Tag.Get("json")
+		if !t.Field(i).IsExported() || tag == "-" {
+			continue
+		}
+		name, opts, _ := strings.Cut(tag, ",")
+		if name == "" {
+			name = t.Field(i).Name
+		}
+		fields = append(fields, recordField{name: name, index: i, omitEmpty: strings.Contains(opts, "omitempty")})
+	}
+	return fields
+}
+
+func cellText(v reflect.Value) string {
+	switch {
+	case v.Kind() == reflect.Pointer || v.Kind() == reflect.Interface:
+		if v.IsNil() {
+			return ""
+		}
+		return cellText(v.Elem())
+	case v.Kind() == reflect.Slice:
+		parts := make([]string, v.Len())
+		for i := range parts {
+			parts[i] = cellText(v.Index(i))
+		}
+		return strings.Join(parts, ", ")
+	case v.Kind() == reflect.Struct && !v.Type().Implements(textMarshaler):
+		// A nested record fits in one cell as JSON
+		data, err := json.Marshal(v.Interface())
+		if err != nil {
+			return fmt.Sprint(v.Interface())
+		}
+		return string(data)
+	}
+	return fmt.Sprint(v.Interface())
+}
+
+// formatDuration renders milliseconds as m:ss
+func formatDuration(ms int) string {
+	d := ms / 1000
+	return fmt.Sprintf("%d:%02d", d/60, d%60)
+}
+
+// Records emitted by --output
+
+type trackRecord struct {
+	Position   int      `json:"position,omitempty"`
+	ID         string   `json:"id"`
+	URI        string   `json:"uri"`
+	Name       string   `json:"name"`
+	Artists    []string `json:"artists"`
+	Album      string   `json:"album"`
+	DurationMS int      `json:"duration_ms"`
+	Duration   string   `json:"duration"`
+}
+
+type playlistRecord struct {
+	Position int    `json:"position"`
+	ID       string `json:"id"`
+	URI      string `json:"uri"`
+	Name     string `json:"name"`
+	Tracks   int    `json:"tracks"`
+}
+
+type profileRecord struct {
+	ID    string `json:"id"`
+	URI   string `json:"uri"`
+	Name  string `json:"name"`
+	Email string `json:"email"`
+	URL   string `json:"url"`
+}
+
+type playbackRecord struct {
+	Action string `json:"action"`
+	URI    string `json:"uri,omitempty"`
+	From   int    `json:"from,omitempty"`  // 1-based track the context started at
+	Value  string `json:"value,omitempty"` // what seek, volume, shuffle and repeat set, or where play started
+	Device string `json:"device,omitempty"`
+}
+
+type logoutRecord struct {
+	Account string   `json:"account"`
+	Removed []string `json:"removed"` // files deleted, empty when not logged in
+}
+
+type accountRecord struct {
+	Name   string `json:"name"`
+	Active bool   `json:"active"`
+	User   string `json:"user"`
+	Email  string `json:"email"`
+}
+
+// nowRecord describes the playback state. It is also what --format templates see,
+// along with its Artist and Playing methods.
+type nowRecord struct {
+	State       string   `json:"state"` // playing, paused or stopped
+	Track       string   `json:"track"`
+	Artists     []string `json:"artists"`
+	Album       string   `json:"album"`
+	URI         string   `json:"uri"`
+	ProgressMS  int      `json:"progress_ms"`
+	DurationMS  int      `json:"duration_ms"`
+	Progress    string   `json:"progress"`
+	Duration    string   `json:"duration"`
+	Device      string   `json:"device"`
+	Volume      int      `json:"volume"` // -1 without volume control
+	Shuffle     bool     `json:"shuffle"`
+	Repeat      string   `json:"repeat"`
+	Context     string   `json:"context"` // playlist, album, artist or show; "" for loose tracks
+	ContextURI  string   `json:"context_uri"`
+	ContextName string   `json:"context_name"`
+}
+
+func newNowRecord(info *PlaybackInfo, contextName string) nowRecord {
+	if info.TrackName == "" {
+		return nowRecord{State: "stopped", Artists: []string{}, Device: info.Device, Volume: info.Volume, Shuffle: info.Shuffle, Repeat: info.Repeat}
+	}
+	state := "paused"
+	if info.IsPlaying {
+		state = "playing"
+	}
+	artists := info.Artists
+	if artists == nil {
+		artists = []string{}
+	}
+	return nowRecord{
+		State:       state,
+		Track:       info.TrackName,
+		Artists:     artists,
+		Album:       info.Album,
+		URI:         info.TrackURI,
+		ProgressMS:  info.ProgressMS,
+		DurationMS:  info.DurationMS,
+		Progress:    formatDuration(info.ProgressMS),
+		Duration:    formatDuration(info.DurationMS),
+		Device:      info.Device,
+		Volume:      info.Volume,
+		Shuffle:     info.Shuffle,
+		Repeat:      info.Repeat,
+		Context:     info.ContextType,
+		ContextURI:  info.ContextURI,
+		ContextName: contextName,
+	}
+}
+
+// Artist joins the artists' names
+func (r nowRecord) Artist() string { return strings.Join(r.Artists, ", ") }
+
+func (r nowRecord) Playing() bool { return r.State == "playing" }
+
+func newTrackRecord(position int, id, uri, name string, artists []string, album string, durationMS int) trackRecord {
+	if artists == nil {
+		artists = []string{}
+	}
+	return trackRecord{
+		Position:   position,
+		ID:         id,
+		URI:        uri,
+		Name:       name,
+		Artists:    artists,
+		Album:      album,
+		DurationMS: durationMS,
+		Duration:   formatDuration(durationMS),
+	}
+}
+
+func init() {
+	rootCmd.PersistentFlags().StringVarP(&outputFormat, "output", "o", "", "print records as "+strings.Join(outputFormats, ", ")+" instead of text")
+	rootCmd.RegisterFlagCompletionFunc("output", cobra.FixedCompletions(outputFormats, cobra.ShellCompDirectiveNoFileComp))
+}
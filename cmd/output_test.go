@@ -0,0 +1,124 @@
+package cmd
+
+import (
+	"bytes"
+	"io"
+	"testing"
+	"time"
+)
+
+type testOwner struct {
+	DisplayName string `json:"display_name"`
+	ID          string `json:"id,omitempty"`
+}
+
+type testRecord struct {
+	Name    string     `json:"name"`
+	Tags    []string   `json:"tags"`
+	Note    string     `json:"note,omitempty"`
+	Count   int        `json:"count,omitempty"`
+	When    *time.Time `json:"when,omitempty"`
+	Owner   testOwner  `json:"owner"`
+	Skipped string     `json:"-"`
+	hidden  string
+}
+
+func TestRenderFormats(t *testing.T) {
+	when := time.Date(2026, 10, 16, 21, 0, 0, 0, time.UTC)
+	records := []testRecord{
+		{Name: "Road Trip", Tags: []string{"pop", "rock"}, Count: 4, When: &when, Owner: testOwner{DisplayName: "Fake User", ID: "fakeuser"}, Skipped: "x", hidden: "y"},
+		{Name: "Tabs\tand\nlines", Owner: testOwner{DisplayName: "Ada"}},
+	}
+
+	tests := []struct {
+		format string
+		want   string
+	}{
+		{outputJSON, `[
+  {
+    "name": "Road Trip",
+    "tags": [
+      "pop",
+      "rock"
+    ],
+    "count": 4,
+    "when": "2026-10-16T21:00:00Z",
+    "owner": {
+      "display_name": "Fake User",
+      "id": "fakeuser"
+    }
+  },
+  {
+    "name": "Tabs\tand\nlines",
+    "tags": null,
+    "owner": {
+      "display_name": "Ada"
+    }
+  }
+]
+`},
+		{outputYAML, `- name: Road Trip
+  tags:
+    - pop
+    - rock
+  count: 4
+  when: 2026-10-16T21:00:00Z
+  owner:
+    display_name: Fake User
+    id: fakeuser
+- name: |-
+    Tabs	and
+    lines
+  tags: []
+  owner:
+    display_name: Ada
+`},
+		{outputTable, `NAME            TAGS       NOTE  COUNT  WHEN                           OWNER
+Road Trip       pop, rock        4      2026-10-16 21:00:00 +0000 UTC  {"display_name":"Fake User","id":"fakeuser"}
+Tabs and lines                                                         {"display_name":"Ada"}
+`},
+		{outputTSV, "NAME\tTAGS\tNOTE\tCOUNT\tWHEN\tOWNER\n" +
+			"Road Trip\tpop, rock\t\t4\t2026-10-16 21:00:00 +0000 UTC\t{\"display_name\":\"Fake User\",\"id\":\"fakeuser\"}\n" +
+			"Tabs and lines\t\t\t\t\t{\"display_name\":\"Ada\"}\n"},
+	}
+	t.Cleanup(func() { outputFormat = "" })
+	for _, tt := range tests {
+		outputFormat = tt.format
+		var out bytes.Buffer
+		if err := renderTo(&out, records, func(io.Writer) { t.Error("human output used with --output") }); err != nil {
+			t.Fatalf("%s: %v", tt.format, err)
+		}
+		if out.String() != tt.want {
+			t.Errorf("%s output:\n%s\nwant:\n%s", tt.format, out.String(), tt.want)
+		}
+	}
+}
+
+func TestRenderSingleRecord(t *testing.T) {
+	t.Cleanup(func() { outputFormat = "" })
+	record := playbackRecord{Action: "seek", Value: "1:30"}
+
+	want := map[string]string{
+		outputJSON: "{\n  \"action\": \"seek\",\n  \"value\": \"1:30\"\n}\n",
+		outputYAML: "action: seek\nvalue: \"1:30\"\n",
+		outputTSV:  "ACTION\tURI\tFROM\tVALUE\tDEVICE\nseek\t\t\t1:30\t\n",
+	}
+	for format, w := range want {
+		outputFormat = format
+		var out bytes.Buffer
+		if err := renderTo(&out, record, nil); err != nil {
+			t.Fatalf("%s: %v", format, err)
+		}
+		if out.String() != w {
+			t.Errorf("%s output %q, want %q", format, out.String(), w)
+		}
+	}
+
+	// Without --output the command's own text is printed
+	outputFormat = ""
+	var out bytes.Buffer
+	renderTo(&out, record, func(w io.Writer) { io.WriteString(w, "Jumped to 1:30\n") })
+	if out.String() != "Jumped to 1:30\n" {
+		t.Errorf("text output %q", out.String())
+	}
+}
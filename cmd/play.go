@@ -0,0 +1,145 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/spf13/cobra"
+)
+
+// playableKinds are the kinds of Spotify item play accepts. Tracks and episodes are
+// played as a list of URIs, the others as a context.
+var playableKinds = []string{"track", "episode", "album", "playlist", "artist", "show"}
+
+// parsePlayable turns a spotify:<kind>:<id> URI or open.spotify.com link into a URI,
+// and says what kind of item it names
+func parsePlayable(ref string) (uri, kind string, ok bool) {
+	for _, kind := range playableKinds {
+		if id, ok := spotifyID(ref, kind); ok {
+			return "spotify:" + kind + ":" + id, kind, true
+		}
+	}
+	return "", "", false
+}
+
+// playOffset reads --offset: a 1-based track number, or the URI or link of a track
+// in the context
+func playOffset(arg string) (*PlaybackOffset, error) {
+	if n, err := strconv.Atoi(arg); err == nil {
+		if n < 1 {
+			return nil, fmt.Errorf("--offset must be 1 or more")
+		}
+		position := n - 1
+		return &PlaybackOffset{Position: &position}, nil
+	}
+	uri, kind, ok := parsePlayable(arg)
+	if !ok || (kind != "track" && kind != "episode") {
+		return nil, fmt.Errorf("invalid --offset %q: use a track number, or a track URI or link", arg)
+	}
+	return &PlaybackOffset{URI: &uri}, nil
+}
+
+var playCmd = &cobra.Command{
+	Use:   "play <uri|link>...",
+	Short: "Play a track, album, playlist, artist or episode by URI or link",
+	Long: `Play anything Spotify has a URI or open.spotify.com link for: a track, album,
+playlist, artist, episode or show. Albums, playlists, artists and shows play as a
+context, so playback carries on through them; several tracks or episodes play one
+after the other.
+
+--offset starts a context or list at a track number, or at a track given by URI or
+link. --position starts partway into that first track, as mm:ss, seconds or a
+duration like 1m30s.
+
+  gitify spotify play spotify:album:4aawyAB9vmqN3uQ7FjRGTy --offset 3
+  gitify spotify play 'https://open.spotify.com/intl-de/track/4uLU6hMCjMI75M1A2tKUQC?si=abc'
+  gitify spotify play spotify:track:4uLU6hMCjMI75M1A2tKUQC --position 1:30`,
+	Args:              cobra.MinimumNArgs(1),
+	ValidArgsFunction: completeTrackURIs,
+	Annotations:       needsScopes("user-modify-playback-state"),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		var req PlaybackRequest
+		var uris []string
+		for _, arg := range args {
+			uri, kind, ok := parsePlayable(arg)
+			if !ok {
+				return fmt.Errorf("%q is not a Spotify URI or open.spotify.com link to a track, album, playlist, artist, episode or show", arg)
+			}
+			if kind != "track" && kind != "episode" {
+				if len(args) > 1 {
+					return fmt.Errorf("play takes one %s at a time, or any number of tracks and episodes", kind)
+				}
+				req.ContextURI = &uri
+				break
+			}
+			uris = append(uris, uri)
+		}
+		if req.ContextURI == nil {
+			req.Uris = &uris
+		}
+
+		from := 0
+		if cmd.Flags().Changed("offset") {
+			arg, _ := cmd.Flags().GetString("offset")
+			offset, err := playOffset(arg)
+			if err != nil {
+				return err
+			}
+			if offset.Position != nil {
+				from = *offset.Position + 1
+				if req.Uris != nil && from > len(uris) {
+					return fmt.Errorf("--offset must be between 1 and %d", len(uris))
+				}
+			}
+			req.Offset = offset
+		}
+
+		position := ""
+		if cmd.Flags().Changed("position") {
+			arg, _ := cmd.Flags().GetString("position")
+			ms, relative, err := parseSeekTarget(arg)
+			if err != nil {
+				return err
+			}
+			if relative {
+				return fmt.Errorf("--position is from the start of the track, so it can't be %q", arg)
+			}
+			req.PositionMS = &ms
+			position = formatDuration(ms)
+		}
+
+		client, err := spotifyClient()
+		if err != nil {
+			return err
+		}
+		if err := StartPlayback(cmd.Context(), client, req); err != nil {
+			return errors.New(describeError(err))
+		}
+
+		target := strings.Join(uris, ", ")
+		if req.ContextURI != nil {
+			target = *req.ContextURI
+		}
+		record := playbackRecord{Action: "play", URI: target, From: from, Value: position, Device: deviceOverride}
+		return render(cmd, record, func(w io.Writer) {
+			msg := "🎶 Playing " + target
+			if from > 1 {
+				msg += fmt.Sprintf(", from track %d", from)
+			}
+			if position != "" {
+				msg += " at " + position
+			}
+			fmt.Fprintln(w, msg)
+		})
+	},
+}
+
+func init() {
+	playCmd.Flags().String("offset", "", "start at this track number, or at a track given by URI or link")
+	playCmd.Flags().String("position", "", "start this far into the first track, e.g. 1:30")
+	addDeviceFlag(playCmd)
+	spotifyCmd.AddCommand(playCmd)
+}
@@ -0,0 +1,53 @@
+package cmd
+
+import "testing"
+
+func TestParsePlayable(t *testing.T) {
+	tests := []struct {
+		ref  string
+		uri  string
+		kind string
+		ok   bool
+	}{
+		{"spotify:track:4uLU6hMCjMI75M1A2tKUQC", "spotify:track:4uLU6hMCjMI75M1A2tKUQC", "track", true},
+		{"spotify:episode:512ojhOuo1ktJprKbVcKyQ", "spotify:episode:512ojhOuo1ktJprKbVcKyQ", "episode", true},
+		{"spotify:album:4aawyAB9vmqN3uQ7FjRGTy", "spotify:album:4aawyAB9vmqN3uQ7FjRGTy", "album", true},
+		{"spotify:artist:0TnOYISbd1XYRBk9myaseg", "spotify:artist:0TnOYISbd1XYRBk9myaseg", "artist", true},
+		{"spotify:show:5CfCWKI5pZ28U0uOzXkDHe", "spotify:show:5CfCWKI5pZ28U0uOzXkDHe", "show", true},
+		{"https://open.spotify.com/track/4uLU6hMCjMI75M1A2tKUQC?si=abc", "spotify:track:4uLU6hMCjMI75M1A2tKUQC", "track", true},
+		{"https://open.spotify.com/intl-de/track/4uLU6hMCjMI75M1A2tKUQC", "spotify:track:4uLU6hMCjMI75M1A2tKUQC", "track", true},
+		{"https://open.spotify.com/album/4aawyAB9vmqN3uQ7FjRGTy/", "spotify:album:4aawyAB9vmqN3uQ7FjRGTy", "album", true},
+		{"https://open.spotify.com/intl-fr/artist/0TnOYISbd1XYRBk9myaseg?si=x", "spotify:artist:0TnOYISbd1XYRBk9myaseg", "artist", true},
+		{"https://open.spotify.com/episode/512ojhOuo1ktJprKbVcKyQ", "spotify:episode:512ojhOuo1ktJprKbVcKyQ", "episode", true},
+		// Not playable, or not well formed
+		{"spotify:user:someone", "", "", false},
+		{"https://open.spotify.com/user/someone", "", "", false},
+		{"spotify:track:", "", "", false},
+		{"spotify:track:4uLU6h/MCjMI", "", "", false},
+		{"https://open.spotify.com/track/", "", "", false},
+		{"https://example.com/track/4uLU6hMCjMI75M1A2tKUQC", "", "", false},
+		{"4uLU6hMCjMI75M1A2tKUQC", "", "", false},
+		{"levitating", "", "", false},
+	}
+	for _, tt := range tests {
+		uri, kind, ok := parsePlayable(tt.ref)
+		if uri != tt.uri || kind != tt.kind || ok != tt.ok {
+			t.Errorf("parsePlayable(%q) = %q, %q, %v, want %q, %q, %v", tt.ref, uri, kind, ok, tt.uri, tt.kind, tt.ok)
+		}
+	}
+}
+
+func TestPlayOffset(t *testing.T) {
+	if off, err := playOffset("3"); err != nil || off.Position == nil || *off.Position != 2 {
+		t.Errorf("playOffset(3) = %+v, %v, want position 2", off, err)
+	}
+	if off, err := playOffset("https://open.spotify.com/track/4uLU6hMCjMI75M1A2tKUQC?si=abc"); err != nil || off.URI == nil || *off.URI != "spotify:track:4uLU6hMCjMI75M1A2tKUQC" {
+		t.Errorf("playOffset(link) = %+v, %v, want the track URI", off, err)
+	}
+	// Only tracks and episodes can be an offset
+	for _, arg := range []string{"0", "spotify:album:4aawyAB9vmqN3uQ7FjRGTy", "first"} {
+		if _, err := playOffset(arg); err == nil {
+			t.Errorf("playOffset(%q) succeeded, want an error", arg)
+		}
+	}
+}
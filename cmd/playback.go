@@ -3,332 +3,396 @@ package cmd
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
 
 	"github.com/adi-253/Gitify/cmd/utils"
 	"github.com/spf13/cobra"
 )
 
 type PlaybackRequest struct {
-    ContextURI *string        `json:"context_uri,omitempty"`
-    Uris       *[]string      `json:"uris,omitempty"`
-    Offset     *PlaybackOffset `json:"offset,omitempty"`
-    PositionMS *int           `json:"position_ms,omitempty"`
+	ContextURI *string         `json:"context_uri,omitempty"`
+	Uris       *[]string       `json:"uris,omitempty"`
+	Offset     *PlaybackOffset `json:"offset,omitempty"`
+	PositionMS *int            `json:"position_ms,omitempty"`
 }
 
 type PlaybackOffset struct {
-    Position *int    `json:"position,omitempty"`
-    URI      *string `json:"uri,omitempty"`
+	Position *int    `json:"position,omitempty"`
+	URI      *string `json:"uri,omitempty"`
 }
 
-// CurrentPlayback represents the response from Spotify's current playback endpoint
-type CurrentPlayback struct {
-    IsPlaying bool `json:"is_playing"`
-    Item      *struct {
-        Name    string `json:"name"`
-        URI     string `json:"uri"`
-        Artists []struct {
-            Name string `json:"name"`
-        } `json:"artists"`
-    } `json:"item"`
+// PlayerState represents the response from Spotify's /me/player endpoint
+type PlayerState struct {
+	Device       *Device `json:"device"`
+	ShuffleState bool    `json:"shuffle_state"`
+	RepeatState  string  `json:"repeat_state"`
+	ProgressMS   int     `json:"progress_ms"`
+	IsPlaying    bool    `json:"is_playing"`
+	Item         *Track  `json:"item"`
+	Context      *struct {
+		Type string `json:"type"`
+		URI  string `json:"uri"`
+	} `json:"context"`
 }
 
 // PlaybackInfo holds simplified playback information for the TUI
 type PlaybackInfo struct {
-    IsPlaying  bool
-    TrackName  string
-    ArtistName string
-    TrackURI   string
+	IsPlaying  bool
+	TrackName  string
+	ArtistName string
+	Artists    []string
+	Album      string
+	TrackURI   string
+	ProgressMS int
+	DurationMS int
+	Shuffle    bool
+	Repeat     string // off, track or context
+	Volume     int    // -1 when the device has no volume control
+	Device     string
+
+	// What the track is playing from; both empty for loose tracks
+	ContextType string // playlist, album, artist or show
+	ContextURI  string
 }
 
-// When Gitify is running inside the Bubble Tea TUI we don't want the
-// playback helpers to print directly to stdout because that corrupts
-// the screen layout and makes list highlighting look wrong.
-var silentPlayback bool
+// errNothingPlaying is returned by commands that act on the current track
+var errNothingPlaying = errors.New("nothing is playing")
+
+// repeatModes are the states accepted by SetRepeat, in the order the TUI cycles them
+var repeatModes = []string{"off", "context", "track"}
+
+// GetPlayerState fetches the full playback state. It returns nil without an error
+// when no device is active.
+func GetPlayerState(ctx context.Context, client *utils.SpotifyClient) (*PlayerState, error) {
+	resp, err := client.GetContext(ctx, client.APIURL("/me/player"))
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	// 204 means no content (no active device)
+	if resp.StatusCode == http.StatusNoContent {
+		return nil, nil
+	}
+
+	if err := utils.CheckResponse(resp); err != nil {
+		return nil, err
+	}
+
+	var state PlayerState
+	if err := json.NewDecoder(resp.Body).Decode(&state); err != nil {
+		return nil, err
+	}
+	return &state, nil
+}
 
-// SetPlaybackSilent controls whether playback helpers print messages.
-// The TUI sets this to true; CLI commands keep the default (false).
-func SetPlaybackSilent(s bool) {
-	silentPlayback = s
+// targetPlayerState fetches the playback state of the device a player command goes to.
+// Spotify only reports it for the active device, so a --device that isn't the active
+// one is an error.
+func targetPlayerState(ctx context.Context, client *utils.SpotifyClient) (*PlayerState, error) {
+	state, err := GetPlayerState(ctx, client)
+	if err != nil || deviceOverride == "" {
+		return state, err
+	}
+
+	d, err := resolveDevice(ctx, client, deviceOverride)
+	if err != nil {
+		return nil, err
+	}
+	if state == nil || state.Device == nil || state.Device.ID != d.ID {
+		return nil, fmt.Errorf("%s isn't playing, so there is nothing to change relative to; give an absolute value or transfer playback to it first", d.Name)
+	}
+	return state, nil
 }
 
 // GetCurrentPlayback fetches the current playback state from Spotify
-func GetCurrentPlayback() (*PlaybackInfo, error) {
-    client, err := utils.NewSpotifyClient()
-    if err != nil {
-        return nil, err
-    }
-
-    resp, err := client.Get("https://api.spotify.com/v1/me/player/currently-playing")
-    if err != nil {
-        return nil, err
-    }
-    defer resp.Body.Close()
-
-    // 204 means no content (nothing playing)
-    if resp.StatusCode == 204 {
-        return &PlaybackInfo{IsPlaying: false}, nil
-    }
-
-    if resp.StatusCode != 200 {
-        return nil, fmt.Errorf("failed to get playback: status %d", resp.StatusCode)
-    }
-
-    var playback CurrentPlayback
-    if err := json.NewDecoder(resp.Body).Decode(&playback); err != nil {
-        return nil, err
-    }
-
-    info := &PlaybackInfo{
-        IsPlaying: playback.IsPlaying,
-    }
-
-    if playback.Item != nil {
-        info.TrackName = playback.Item.Name
-        info.TrackURI = playback.Item.URI
-        var artists []string
-        for _, a := range playback.Item.Artists {
-            artists = append(artists, a.Name)
-        }
-        if len(artists) > 0 {
-            info.ArtistName = artists[0]
-            if len(artists) > 1 {
-                for i := 1; i < len(artists); i++ {
-                    info.ArtistName += ", " + artists[i]
-                }
-            }
-        }
-    }
-
-    return info, nil
+func GetCurrentPlayback(ctx context.Context, client *utils.SpotifyClient) (*PlaybackInfo, error) {
+	state, err := GetPlayerState(ctx, client)
+	if err != nil {
+		return nil, err
+	}
+	if state == nil {
+		return &PlaybackInfo{IsPlaying: false, Volume: -1}, nil
+	}
+
+	info := &PlaybackInfo{
+		IsPlaying:  state.IsPlaying,
+		ProgressMS: state.ProgressMS,
+		Shuffle:    state.ShuffleState,
+		Repeat:     state.RepeatState,
+		Volume:     -1,
+	}
+	if state.Device != nil {
+		info.Device = state.Device.Name
+		if state.Device.VolumePercent != nil {
+			info.Volume = *state.Device.VolumePercent
+		}
+	}
+	if state.Item != nil {
+		info.TrackName = state.Item.Name
+		info.TrackURI = state.Item.URI
+		info.ArtistName = joinArtists(state.Item.Artists)
+		info.Album = state.Item.Album.Name
+		info.DurationMS = state.Item.DurationMS
+		for _, a := range state.Item.Artists {
+			info.Artists = append(info.Artists, a.Name)
+		}
+	}
+	if state.Context != nil {
+		info.ContextType = state.Context.Type
+		info.ContextURI = state.Context.URI
+	}
+
+	return info, nil
 }
 
-func StartMusic(contextURI *string, uris *[]string) {
-    StartMusicWithOffset(contextURI, uris, nil)
+func StartMusic(ctx context.Context, client *utils.SpotifyClient, contextURI *string, uris *[]string) error {
+	return StartMusicWithOffset(ctx, client, contextURI, uris, nil)
 }
 
 // StartMusicWithOffset starts playback with an optional offset position
-func StartMusicWithOffset(contextURI *string, uris *[]string, offsetPosition *int) {
-    req := PlaybackRequest{
-        ContextURI: contextURI,
-        Uris:       uris,
-        Offset:     nil,
-        PositionMS: nil,
-    }
-
-    // If offset position is provided, set it
-    if offsetPosition != nil {
-        req.Offset = &PlaybackOffset{
-            Position: offsetPosition,
-        }
-    }
-
-	
-    buf := new(bytes.Buffer)
-    json.NewEncoder(buf).Encode(req)
-
-    client, err := utils.NewSpotifyClient()
-    if err != nil {
-        if !silentPlayback {
-            fmt.Printf("Error creating Spotify client: %v\n", err)
-        }
-        return
-    }
-    
-    resp, err := client.Put("https://api.spotify.com/v1/me/player/play", buf)
-    if err != nil {
-        if !silentPlayback {
-            fmt.Printf("Error starting playback: %v\n", err)
-        }
-        return
-    }
-    defer resp.Body.Close()
-    
-    if resp.StatusCode == 404 {
-        if !silentPlayback {
-            fmt.Println("No active device found. Please:")
-            fmt.Println("   1. Open Spotify app on your phone/computer")
-            fmt.Println("   2. Start playing any song briefly")
-            fmt.Println("   3. Then try again")
-        }
-        return
-    }
-    
-    if resp.StatusCode != 204 {
-        if !silentPlayback {
-            fmt.Printf("Playback failed with status: %d\n", resp.StatusCode)
-        }
-        return
-    }
-    
-    if !silentPlayback {
-        fmt.Println("Playback started successfully!")
-    }
+func StartMusicWithOffset(ctx context.Context, client *utils.SpotifyClient, contextURI *string, uris *[]string, offsetPosition *int) error {
+	req := PlaybackRequest{
+		ContextURI: contextURI,
+		Uris:       uris,
+		Offset:     nil,
+		PositionMS: nil,
+	}
+
+	// If offset position is provided, set it
+	if offsetPosition != nil {
+		req.Offset = &PlaybackOffset{
+			Position: offsetPosition,
+		}
+	}
+
+	return StartPlayback(ctx, client, req)
+}
+
+// StartPlayback sends a full play request, for callers that need an offset by URI
+// or a start position
+func StartPlayback(ctx context.Context, client *utils.SpotifyClient, req PlaybackRequest) error {
+	buf := new(bytes.Buffer)
+	if err := json.NewEncoder(buf).Encode(req); err != nil {
+		return err
+	}
+
+	return playerCommand(ctx, client, http.MethodPut, "/me/player/play", buf)
 }
 
-func PausePlayback() {
-    client, err := utils.NewSpotifyClient()
-    if err != nil {
-        if !silentPlayback {
-            fmt.Printf("Error creating Spotify client: %v\n", err)
-        }
-        return
-    }
-
-    resp, err := client.Put("https://api.spotify.com/v1/me/player/pause", nil)
-    if err != nil {
-        if !silentPlayback {
-            fmt.Printf("Error pausing playback: %v\n", err)
-        }
-        return
-    }
-    defer resp.Body.Close()
-
-    if !silentPlayback {
-        switch resp.StatusCode {
-        case 200:
-            fmt.Println("Playback paused")
-        case 404:
-            fmt.Println("No active device found")
-        case 403:
-            fmt.Println("Playback control requires Spotify Premium")
-        default:
-            fmt.Printf("Failed to pause with status: %d\n", resp.StatusCode)
-        }
-    }
+func PausePlayback(ctx context.Context, client *utils.SpotifyClient) error {
+	return playerCommand(ctx, client, http.MethodPut, "/me/player/pause", nil)
 }
 
-func ResumePlayback() {
-    client, err := utils.NewSpotifyClient()
-    if err != nil {
-        if !silentPlayback {
-            fmt.Printf("Error creating Spotify client: %v\n", err)
-        }
-        return
-    }
-
-    resp, err := client.Put("https://api.spotify.com/v1/me/player/play", nil)
-    if err != nil {
-        if !silentPlayback {
-            fmt.Printf("Error resuming playback: %v\n", err)
-        }
-        return
-    }
-    defer resp.Body.Close()
-
-    if !silentPlayback {
-        switch resp.StatusCode {
-        case 200:
-            fmt.Println("Playback resumed")
-        case 404:
-            fmt.Println("No active device found")
-        case 403:
-            fmt.Println("Playback control requires Spotify Premium")
-        default:
-            fmt.Printf("Failed to resume with status: %d\n", resp.StatusCode)
-        }
-    }
+func ResumePlayback(ctx context.Context, client *utils.SpotifyClient) error {
+	return playerCommand(ctx, client, http.MethodPut, "/me/player/play", nil)
 }
 
-func NextTrack() {
-    client, err := utils.NewSpotifyClient()
-    if err != nil {
-        if !silentPlayback {
-            fmt.Printf("Error creating Spotify client: %v\n", err)
-        }
-        return
-    }
-
-    resp, err := client.Post("https://api.spotify.com/v1/me/player/next", nil)
-    if err != nil {
-        if !silentPlayback {
-            fmt.Printf("Error skipping track: %v\n", err)
-        }
-        return
-    }
-    defer resp.Body.Close()
-
-    if !silentPlayback {
-        switch resp.StatusCode {
-        case 200:
-            fmt.Println("Skipped to next track")
-        case 404:
-            fmt.Println("No active device found")
-        case 403:
-            fmt.Println("Playback control requires Spotify Premium")
-        default:
-            fmt.Printf("Failed to skip with status: %d\n", resp.StatusCode)
-        }
-    }
+func NextTrack(ctx context.Context, client *utils.SpotifyClient) error {
+	return playerCommand(ctx, client, http.MethodPost, "/me/player/next", nil)
 }
 
-func PreviousTrack() {
-    client, err := utils.NewSpotifyClient()
-    if err != nil {
-        if !silentPlayback {
-            fmt.Printf("Error creating Spotify client: %v\n", err)
-        }
-        return
-    }
-
-    resp, err := client.Post("https://api.spotify.com/v1/me/player/previous", nil)
-    if err != nil {
-        if !silentPlayback {
-            fmt.Printf("Error going to previous track: %v\n", err)
-        }
-        return
-    }
-    defer resp.Body.Close()
-
-    if !silentPlayback {
-        switch resp.StatusCode {
-        case 200:
-            fmt.Println("Previous track")
-        case 404:
-            fmt.Println("No active device found")
-        case 403:
-            fmt.Println("Playback control requires Spotify Premium")
-        default:
-            fmt.Printf("Failed to go to previous track with status: %d\n", resp.StatusCode)
-        }
-    }
+func PreviousTrack(ctx context.Context, client *utils.SpotifyClient) error {
+	return playerCommand(ctx, client, http.MethodPost, "/me/player/previous", nil)
+}
+
+// Seek jumps to a position in the current track
+func Seek(ctx context.Context, client *utils.SpotifyClient, positionMS int) error {
+	return playerCommand(ctx, client, http.MethodPut, "/me/player/seek?position_ms="+strconv.Itoa(positionMS), nil)
+}
+
+// SeekBy moves deltaMS forward (or back, when negative) in the current track, staying
+// within it, and returns the new position
+func SeekBy(ctx context.Context, client *utils.SpotifyClient, deltaMS int) (int, error) {
+	state, err := targetPlayerState(ctx, client)
+	if err != nil {
+		return 0, err
+	}
+	if state == nil || state.Item == nil {
+		return 0, errNothingPlaying
+	}
+
+	position := min(max(state.ProgressMS+deltaMS, 0), state.Item.DurationMS)
+	return position, Seek(ctx, client, position)
+}
+
+// SetVolume sets the volume of the device, 0 to 100
+func SetVolume(ctx context.Context, client *utils.SpotifyClient, percent int) error {
+	return playerCommand(ctx, client, http.MethodPut, "/me/player/volume?volume_percent="+strconv.Itoa(percent), nil)
+}
+
+// ChangeVolume turns the volume up (or down, when negative) by delta points and
+// returns the new volume. It changes the --device device if given, else the active one.
+func ChangeVolume(ctx context.Context, client *utils.SpotifyClient, delta int) (int, error) {
+	var device *Device
+	if deviceOverride != "" {
+		d, err := resolveDevice(ctx, client, deviceOverride)
+		if err != nil {
+			return 0, err
+		}
+		device = &d
+	} else {
+		state, err := GetPlayerState(ctx, client)
+		if err != nil {
+			return 0, err
+		}
+		if state == nil || state.Device == nil {
+			return 0, utils.ErrNoActiveDevice
+		}
+		device = state.Device
+	}
+	if device.VolumePercent == nil {
+		return 0, fmt.Errorf("%s has no volume control", device.Name)
+	}
+
+	volume := min(max(*device.VolumePercent+delta, 0), 100)
+	return volume, SetVolume(ctx, client, volume)
+}
+
+// SetShuffle turns shuffle on or off
+func SetShuffle(ctx context.Context, client *utils.SpotifyClient, on bool) error {
+	return playerCommand(ctx, client, http.MethodPut, "/me/player/shuffle?state="+strconv.FormatBool(on), nil)
+}
+
+// SetRepeat sets the repeat mode: off, track or context
+func SetRepeat(ctx context.Context, client *utils.SpotifyClient, mode string) error {
+	return playerCommand(ctx, client, http.MethodPut, "/me/player/repeat?state="+url.QueryEscape(mode), nil)
+}
+
+// playerCommand sends a request to a /me/player endpoint and turns any non-2xx
+// response into a typed error (see utils.ErrNoActiveDevice and friends). It targets
+// the --device device if given, else the active one; when no device is active it
+// tries again on the default_device.
+func playerCommand(ctx context.Context, client *utils.SpotifyClient, method, path string, body io.Reader) error {
+	var payload []byte
+	if body != nil {
+		var err error
+		if payload, err = io.ReadAll(body); err != nil {
+			return err
+		}
+	}
+
+	device := deviceOverride
+	if device == "" {
+		err := sendPlayerCommand(ctx, client, method, client.APIURL(path), payload)
+		if !errors.Is(err, utils.ErrNoActiveDevice) || utils.DefaultDevice == "" {
+			return err
+		}
+		device = utils.DefaultDevice
+	}
+
+	d, err := resolveDevice(ctx, client, device)
+	if err != nil {
+		return err
+	}
+	sep := "?"
+	if strings.Contains(path, "?") {
+		sep = "&"
+	}
+	return sendPlayerCommand(ctx, client, method, client.APIURL(path)+sep+"device_id="+url.QueryEscape(d.ID), payload)
+}
+
+func sendPlayerCommand(ctx context.Context, client *utils.SpotifyClient, method, endpoint string, payload []byte) error {
+	var body io.Reader
+	if payload != nil {
+		body = bytes.NewReader(payload)
+	}
+
+	var resp *http.Response
+	var err error
+	switch method {
+	case http.MethodPost:
+		resp, err = client.PostContext(ctx, endpoint, body)
+	default:
+		resp, err = client.PutContext(ctx, endpoint, body)
+	}
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	return utils.CheckResponse(resp)
+}
+
+// describeError turns playback and API errors into a one-line message for the CLI and TUI
+func describeError(err error) string {
+	switch {
+	case errors.Is(err, utils.ErrNoActiveDevice):
+		return "No active device found. Pick one with `gitify spotify transfer <device>` or --device (see `gitify spotify devices`), or set default_device"
+	case errors.Is(err, utils.ErrPremiumRequired):
+		return "Playback control requires Spotify Premium"
+	case errors.Is(err, utils.ErrRateLimited):
+		return "Spotify is rate limiting requests, please try again in a moment"
+	case errors.Is(err, utils.ErrUnauthorized):
+		return "Your Spotify session has expired, run `gitify spotify login` again"
+	case errors.Is(err, utils.ErrInsufficientScope):
+		return "Spotify refused a permission this needs, run `gitify spotify login` again and approve it"
+	case errors.Is(err, context.Canceled):
+		return "Cancelled"
+	default:
+		return err.Error()
+	}
+}
+
+// playbackRunE wraps a playback helper as a cobra RunE that prints done on success,
+// or with --output a record naming the command
+func playbackRunE(action func(ctx context.Context, client *utils.SpotifyClient) error, done string) func(cmd *cobra.Command, args []string) error {
+	return func(cmd *cobra.Command, args []string) error {
+		client, err := spotifyClient()
+		if err != nil {
+			return err
+		}
+		if err := action(cmd.Context(), client); err != nil {
+			return errors.New(describeError(err))
+		}
+		return render(cmd, playbackRecord{Action: cmd.Name(), Device: deviceOverride}, func(w io.Writer) {
+			fmt.Fprintln(w, done)
+		})
+	}
 }
 
 // CLI Commands
 var pauseCmd = &cobra.Command{
-	Use:   "pause",
-	Short: "Pause current playback",
-	Run: func(cmd *cobra.Command, args []string) {
-		PausePlayback()
-	},
+	Use:         "pause",
+	Short:       "Pause current playback",
+	Annotations: needsScopes("user-modify-playback-state"),
+	RunE:        playbackRunE(PausePlayback, "Playback paused"),
 }
 
 var resumeCmd = &cobra.Command{
-	Use:   "resume",
-	Short: "Resume current playback", 
-	Run: func(cmd *cobra.Command, args []string) {
-		ResumePlayback()
-	},
+	Use:         "resume",
+	Short:       "Resume current playback",
+	Annotations: needsScopes("user-modify-playback-state"),
+	RunE:        playbackRunE(ResumePlayback, "Playback resumed"),
 }
 
 var nextCmd = &cobra.Command{
-	Use:   "next",
-	Short: "Skip to next track",
-	Run: func(cmd *cobra.Command, args []string) {
-		NextTrack()
-	},
+	Use:         "next",
+	Short:       "Skip to next track",
+	Annotations: needsScopes("user-modify-playback-state"),
+	RunE:        playbackRunE(NextTrack, "Skipped to next track"),
 }
 
 var prevCmd = &cobra.Command{
-	Use:   "prev",
-	Short: "Go to previous track",
-	Run: func(cmd *cobra.Command, args []string) {
-		PreviousTrack()
-	},
+	Use:         "prev",
+	Short:       "Go to previous track",
+	Annotations: needsScopes("user-modify-playback-state"),
+	RunE:        playbackRunE(PreviousTrack, "Previous track"),
 }
 
 func init() {
+	for _, cmd := range []*cobra.Command{pauseCmd, resumeCmd, nextCmd, prevCmd} {
+		addDeviceFlag(cmd)
+	}
+
 	spotifyCmd.AddCommand(pauseCmd)
 	spotifyCmd.AddCommand(resumeCmd)
 	spotifyCmd.AddCommand(nextCmd)
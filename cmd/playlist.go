@@ -1,10 +1,14 @@
 package cmd
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
+	"io/fs"
 	"net/url"
-	"os"
+	"regexp"
 	"strings"
 
 	"github.com/adi-253/Gitify/cmd/utils"
@@ -23,14 +27,15 @@ type Playlist struct {
 	Name   string `json:"name"`
 	ID     string `json:"id"`
 	Tracks struct {
-		Href string `json:"href"`
+		Href  string `json:"href"`
+		Total int    `json:"total"`
 	} `json:"tracks"`
 	Uri string `json:"uri"`
 }
 
 type PlaylistTracksResponse struct {
 	Items []PlaylistTrack `json:"items"`
-	Next  string           `json:"next"`
+	Next  string          `json:"next"`
 }
 
 type PlaylistTrack struct {
@@ -42,6 +47,10 @@ type Track struct {
 	ID      string   `json:"id"`
 	Artists []Artist `json:"artists"`
 	URI     string   `json:"uri"` // Added for playback functionality
+	Album   struct {
+		Name string `json:"name"`
+	} `json:"album"`
+	DurationMS int `json:"duration_ms"`
 }
 
 type Artist struct {
@@ -53,36 +62,25 @@ type Artist struct {
 var playlistCmd = &cobra.Command{
 	Use:   "playlist",
 	Short: "Fetch and view user playlists and tracks",
-	Run: func(cmd *cobra.Command, args []string) {
-		data, err := os.ReadFile("profile.json")
-		if err != nil {
-			fmt.Println("Could not get user data. Please login again.")
-			return
-		}
+	Long: `List your playlists and, on a terminal, pick one to view and play.
 
-		var userinfo Profile
-		err = json.Unmarshal(data, &userinfo)
+Scripts should use the list, tracks and play subcommands, which never prompt. When
+stdin is not a terminal, or --output is given, this just lists the playlists.`,
+	Annotations: needsScopes("playlist-read-private"),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		client, allPlaylists, err := userPlaylists(cmd.Context())
 		if err != nil {
-			fmt.Println("Could not parse user data.")
-			return
+			return err
 		}
 
-		client, err := utils.NewSpotifyClient()
-		if err != nil {
-			fmt.Printf("Error creating Spotify client: %s\n", err)
-			return
-		}
-
-		baseURL := "https://api.spotify.com/v1/users/" + userinfo.Userid + "/playlists"
-		allPlaylists, err := fetchAllPlaylists(client, baseURL)
-		if err != nil {
-			fmt.Printf("Error fetching playlists: %s\n", err)
-			return
+		// Nobody is there to answer the prompts below
+		if !interactive() {
+			return printPlaylists(cmd, allPlaylists)
 		}
 
 		if len(allPlaylists) == 0 {
 			fmt.Println("No playlists found.")
-			return
+			return nil
 		}
 
 		fmt.Printf("\n🎵 You have %d playlists:\n\n", len(allPlaylists))
@@ -94,17 +92,15 @@ var playlistCmd = &cobra.Command{
 		var choice int
 		_, err = fmt.Scan(&choice)
 		if err != nil || choice < 1 || choice > len(allPlaylists) {
-			fmt.Println("Invalid choice.")
-			return
+			return fmt.Errorf("invalid choice")
 		}
 
 		selected := allPlaylists[choice-1]
 		fmt.Printf("\nFetching songs for: %s\n\n", selected.Name)
 
-		tracks, err := fetchAllTracks(client, selected.Tracks.Href)
+		tracks, err := fetchAllTracks(cmd.Context(), client, selected.Tracks.Href)
 		if err != nil {
-			fmt.Printf("Error fetching tracks: %s\n", err)
-			return
+			return fmt.Errorf("error fetching tracks: %s", describeError(err))
 		}
 
 		for i, t := range tracks {
@@ -116,31 +112,244 @@ var playlistCmd = &cobra.Command{
 		fmt.Println("[P] Play entire playlist")
 		fmt.Println("[Q] Quit")
 		fmt.Print("\nChoose an option: ")
-		
+
 		var playChoice string
 		fmt.Scan(&playChoice)
-		
+
 		switch strings.ToUpper(playChoice) {
 		case "P":
 			// Play entire playlist using context URI (format: spotify:playlist:ID)
-			playlistURI := selected.Uri
-			if playlistURI == "" {
-				playlistURI = "spotify:playlist:" + selected.ID
+			playlistURI := selected.uri()
+
+			// Only playing needs control of playback, so it is asked for here
+			if err := requireScopes(cmd, "user-modify-playback-state"); err != nil {
+				return err
+			}
+			// A login for the scope replaced the client
+			if client, err = spotifyClient(); err != nil {
+				return err
 			}
 
 			fmt.Printf("\n🎶 Playing playlist: %s\n", selected.Name)
-			StartMusic(&playlistURI, nil)
+			if err := StartMusic(cmd.Context(), client, &playlistURI, nil); err != nil {
+				return errors.New(describeError(err))
+			}
+			fmt.Println("Playback started successfully!")
 		case "Q":
 			fmt.Println("Goodbye! 👋")
 		default:
-			fmt.Println("Invalid option.")
+			return fmt.Errorf("invalid option")
+		}
+		return nil
+	},
+}
+
+var playlistListCmd = &cobra.Command{
+	Use:         "list",
+	Short:       "List your playlists",
+	Args:        cobra.NoArgs,
+	Annotations: needsScopes("playlist-read-private"),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		_, playlists, err := userPlaylists(cmd.Context())
+		if err != nil {
+			return err
+		}
+		return printPlaylists(cmd, playlists)
+	},
+}
+
+var playlistTracksCmd = &cobra.Command{
+	Use:   "tracks <name|id|uri>",
+	Short: "List the tracks of a playlist",
+	Long: `List the tracks of a playlist, given by name (case insensitive), ID,
+spotify:playlist: URI or open.spotify.com link.`,
+	Args:              cobra.ExactArgs(1),
+	ValidArgsFunction: completePlaylists,
+	Annotations:       needsScopes("playlist-read-private"),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		client, playlist, err := resolvePlaylist(cmd.Context(), args[0])
+		if err != nil {
+			return err
 		}
+
+		tracks, err := fetchAllTracks(cmd.Context(), client, playlist.Tracks.Href)
+		if err != nil {
+			return fmt.Errorf("error fetching tracks: %s", describeError(err))
+		}
+
+		return render(cmd, playlistTrackRecords(tracks), func(w io.Writer) {
+			for i, t := range tracks {
+				fmt.Fprintf(w, "%d. %s — %s\n", i+1, t.Name, joinArtists(t.Artists))
+			}
+		})
+	},
+}
+
+var playlistPlayCmd = &cobra.Command{
+	Use:   "play <name|id|uri>",
+	Short: "Play a playlist",
+	Long: `Play a playlist, given like for "playlist tracks". --from starts at a track
+number instead of the first one.`,
+	Args:              cobra.ExactArgs(1),
+	ValidArgsFunction: completePlaylists,
+	Annotations:       needsScopes("playlist-read-private", "user-modify-playback-state"),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		from, _ := cmd.Flags().GetInt("from")
+		if from < 1 {
+			return fmt.Errorf("--from must be 1 or more")
+		}
+
+		client, playlist, err := resolvePlaylist(cmd.Context(), args[0])
+		if err != nil {
+			return err
+		}
+
+		playlistURI := playlist.uri()
+		offset := from - 1
+		if err := StartMusicWithOffset(cmd.Context(), client, &playlistURI, nil, &offset); err != nil {
+			return errors.New(describeError(err))
+		}
+
+		record := playbackRecord{Action: "play", URI: playlistURI, From: from, Device: deviceOverride}
+		return render(cmd, record, func(w io.Writer) {
+			if from > 1 {
+				fmt.Fprintf(w, "🎶 Playing playlist: %s, from track %d\n", playlist.Name, from)
+				return
+			}
+			fmt.Fprintf(w, "🎶 Playing playlist: %s\n", playlist.Name)
+		})
 	},
 }
 
 // ---------------- Helper Functions ----------------
 
-func fetchAllPlaylists(client *utils.SpotifyClient, href string) ([]Playlist, error) {
+// userPlaylists fetches every playlist of the logged in user
+func userPlaylists(ctx context.Context) (*utils.SpotifyClient, []Playlist, error) {
+	userinfo, err := loadProfile()
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil, fmt.Errorf("could not get user data, please login again")
+	}
+	if err != nil {
+		return nil, nil, fmt.Errorf("could not parse user data")
+	}
+
+	client, err := spotifyClient()
+	if err != nil {
+		return nil, nil, fmt.Errorf("error creating Spotify client: %s", err)
+	}
+
+	playlists, err := fetchAllPlaylists(ctx, client, client.APIURL("/users/"+userinfo.Userid+"/playlists"))
+	if err != nil {
+		return nil, nil, fmt.Errorf("error fetching playlists: %s", describeError(err))
+	}
+	saveForCompletion(playlistsCache, playlists)
+	return client, playlists, nil
+}
+
+// resolvePlaylist finds a playlist by ID, URI, link or name among the user's playlists.
+// A URI, link or ID-shaped ref naming a playlist the user doesn't have is used as is.
+func resolvePlaylist(ctx context.Context, ref string) (*utils.SpotifyClient, Playlist, error) {
+	client, playlists, err := userPlaylists(ctx)
+	if err != nil {
+		return nil, Playlist{}, err
+	}
+
+	id, isRef := spotifyID(ref, "playlist")
+	if !isRef {
+		id = ref
+	}
+	for _, p := range playlists {
+		if p.ID == id {
+			return client, p, nil
+		}
+	}
+	if isRef {
+		return client, playlistByID(client, id), nil
+	}
+
+	var matches []Playlist
+	for _, p := range playlists {
+		if strings.EqualFold(p.Name, ref) {
+			matches = append(matches, p)
+		}
+	}
+	switch len(matches) {
+	case 0:
+		// Names rarely look like IDs, so a ref that does is only taken as one here
+		if spotifyIDRe.MatchString(ref) {
+			return client, playlistByID(client, ref), nil
+		}
+		return nil, Playlist{}, fmt.Errorf("no playlist named %q, see `gitify spotify playlist list`", ref)
+	case 1:
+		return client, matches[0], nil
+	default:
+		return nil, Playlist{}, fmt.Errorf("%d playlists are named %q, use the ID or URI instead", len(matches), ref)
+	}
+}
+
+// playlistByID stands in for a playlist that isn't in the user's list
+func playlistByID(client *utils.SpotifyClient, id string) Playlist {
+	p := Playlist{Name: id, ID: id, Uri: "spotify:playlist:" + id}
+	p.Tracks.Href = client.APIURL("/playlists/" + id + "/tracks")
+	return p
+}
+
+// spotifyIDRe matches a bare Spotify ID: 22 base62 characters
+var spotifyIDRe = regexp.MustCompile(`^[0-9A-Za-z]{22}$`)
+
+// spotifyIDChars matches what an ID in a URI or link may contain
+var spotifyIDChars = regexp.MustCompile(`^[0-9A-Za-z]+$`)
+
+// spotifyID returns the ID in a spotify:<kind>:<id> URI or an open.spotify.com/<kind>/<id>
+// link, and whether ref was one with a well-formed ID
+func spotifyID(ref, kind string) (string, bool) {
+	if id, ok := strings.CutPrefix(ref, "spotify:"+kind+":"); ok {
+		return validID(id)
+	}
+
+	u, err := url.Parse(ref)
+	if err != nil || u.Host != "open.spotify.com" {
+		return "", false
+	}
+	// Links may carry a locale first, e.g. /intl-de/playlist/<id>
+	parts := strings.Split(strings.Trim(u.Path, "/"), "/")
+	for i := 0; i+1 < len(parts); i++ {
+		if parts[i] == kind {
+			return validID(parts[i+1])
+		}
+	}
+	return "", false
+}
+
+func validID(id string) (string, bool) {
+	if !spotifyIDChars.MatchString(id) {
+		return "", false
+	}
+	return id, true
+}
+
+// printPlaylists prints a numbered list of playlists, or records with --output
+func printPlaylists(cmd *cobra.Command, playlists []Playlist) error {
+	return render(cmd, playlistRecords(playlists), func(w io.Writer) {
+		if len(playlists) == 0 {
+			fmt.Fprintln(w, "No playlists found.")
+			return
+		}
+		for i, p := range playlists {
+			fmt.Fprintf(w, "[%d] %s\n", i+1, p.Name)
+		}
+	})
+}
+
+// uri returns the playlist's context URI, built from its ID if Spotify left it out
+func (p Playlist) uri() string {
+	if p.Uri == "" {
+		return "spotify:playlist:" + p.ID
+	}
+	return p.Uri
+}
+
+func fetchAllPlaylists(ctx context.Context, client *utils.SpotifyClient, href string) ([]Playlist, error) {
 	var all []Playlist
 	next := href
 
@@ -152,14 +361,18 @@ func fetchAllPlaylists(client *utils.SpotifyClient, href string) ([]Playlist, er
 		}
 		u.RawQuery = q.Encode()
 
-		resp, err := client.Get(u.String())
+		resp, err := client.GetContext(ctx, u.String())
 		if err != nil {
 			return nil, err
 		}
-		defer resp.Body.Close()
 
 		var res PlaylistsResponse
-		if err := json.NewDecoder(resp.Body).Decode(&res); err != nil {
+		err = utils.CheckResponse(resp)
+		if err == nil {
+			err = json.NewDecoder(resp.Body).Decode(&res)
+		}
+		resp.Body.Close()
+		if err != nil {
 			return nil, err
 		}
 
@@ -170,7 +383,7 @@ func fetchAllPlaylists(client *utils.SpotifyClient, href string) ([]Playlist, er
 	return all, nil
 }
 
-func fetchAllTracks(client *utils.SpotifyClient, href string) ([]Track, error) {
+func fetchAllTracks(ctx context.Context, client *utils.SpotifyClient, href string) ([]Track, error) {
 	var all []Track
 	next := href
 
@@ -182,14 +395,18 @@ func fetchAllTracks(client *utils.SpotifyClient, href string) ([]Track, error) {
 		}
 		u.RawQuery = q.Encode()
 
-		resp, err := client.Get(u.String())
+		resp, err := client.GetContext(ctx, u.String())
 		if err != nil {
 			return nil, err
 		}
-		defer resp.Body.Close()
 
 		var res PlaylistTracksResponse
-		if err := json.NewDecoder(resp.Body).Decode(&res); err != nil {
+		err = utils.CheckResponse(resp)
+		if err == nil {
+			err = json.NewDecoder(resp.Body).Decode(&res)
+		}
+		resp.Body.Close()
+		if err != nil {
 			return nil, err
 		}
 
@@ -203,6 +420,26 @@ func fetchAllTracks(client *utils.SpotifyClient, href string) ([]Track, error) {
 	return all, nil
 }
 
+func playlistRecords(playlists []Playlist) []playlistRecord {
+	records := make([]playlistRecord, len(playlists))
+	for i, p := range playlists {
+		records[i] = playlistRecord{Position: i + 1, ID: p.ID, URI: p.uri(), Name: p.Name, Tracks: p.Tracks.Total}
+	}
+	return records
+}
+
+func playlistTrackRecords(tracks []Track) []trackRecord {
+	records := make([]trackRecord, len(tracks))
+	for i, t := range tracks {
+		artists := make([]string, len(t.Artists))
+		for j, a := range t.Artists {
+			artists[j] = a.Name
+		}
+		records[i] = newTrackRecord(i+1, t.ID, t.URI, t.Name, artists, t.Album.Name, t.DurationMS)
+	}
+	return records
+}
+
 func joinArtists(artists []Artist) string {
 	names := ""
 	for i, a := range artists {
@@ -215,5 +452,12 @@ func joinArtists(artists []Artist) string {
 }
 
 func init() {
+	playlistPlayCmd.Flags().Int("from", 1, "track number to start at")
+	addDeviceFlag(playlistPlayCmd)
+	addDeviceFlag(playlistCmd)
+
+	playlistCmd.AddCommand(playlistListCmd)
+	playlistCmd.AddCommand(playlistTracksCmd)
+	playlistCmd.AddCommand(playlistPlayCmd)
 	spotifyCmd.AddCommand(playlistCmd)
 }
@@ -0,0 +1,47 @@
+package cmd
+
+import "testing"
+
+func TestSpotifyID(t *testing.T) {
+	tests := []struct {
+		ref, kind string
+		id        string
+		ok        bool
+	}{
+		{"spotify:playlist:37i9dQZF1DXcBWIGoYBM5M", "playlist", "37i9dQZF1DXcBWIGoYBM5M", true},
+		{"https://open.spotify.com/playlist/37i9dQZF1DXcBWIGoYBM5M", "playlist", "37i9dQZF1DXcBWIGoYBM5M", true},
+		{"https://open.spotify.com/playlist/37i9dQZF1DXcBWIGoYBM5M?si=abc123", "playlist", "37i9dQZF1DXcBWIGoYBM5M", true},
+		{"https://open.spotify.com/playlist/37i9dQZF1DXcBWIGoYBM5M/", "playlist", "37i9dQZF1DXcBWIGoYBM5M", true},
+		{"https://open.spotify.com/intl-de/playlist/37i9dQZF1DXcBWIGoYBM5M", "playlist", "37i9dQZF1DXcBWIGoYBM5M", true},
+		{"https://open.spotify.com/intl-pt/album/4aawyAB9vmqN3uQ7FjRGTy?si=x&nd=1", "album", "4aawyAB9vmqN3uQ7FjRGTy", true},
+		// Another kind, host or a bad ID is no match
+		{"spotify:album:4aawyAB9vmqN3uQ7FjRGTy", "playlist", "", false},
+		{"https://example.com/playlist/37i9dQZF1DXcBWIGoYBM5M", "playlist", "", false},
+		{"spotify:playlist:", "playlist", "", false},
+		{"spotify:playlist:bad id", "playlist", "", false},
+		{"https://open.spotify.com/playlist/", "playlist", "", false},
+		{"https://open.spotify.com/playlist/abc-def", "playlist", "", false},
+		{"Road Trip", "playlist", "", false},
+	}
+	for _, tt := range tests {
+		id, ok := spotifyID(tt.ref, tt.kind)
+		if id != tt.id || ok != tt.ok {
+			t.Errorf("spotifyID(%q, %q) = %q, %v, want %q, %v", tt.ref, tt.kind, id, ok, tt.id, tt.ok)
+		}
+	}
+}
+
+func TestSpotifyIDRe(t *testing.T) {
+	tests := map[string]bool{
+		"37i9dQZF1DXcBWIGoYBM5M":  true,
+		"37i9dQZF1DXcBWIGoYBM5":   false, // 21 characters
+		"37i9dQZF1DXcBWIGoYBM5MX": false, // 23
+		"37i9dQZF1DXcBWIGoYBM5-":  false,
+		"Road Trip":               false,
+	}
+	for ref, want := range tests {
+		if got := spotifyIDRe.MatchString(ref); got != want {
+			t.Errorf("spotifyIDRe.MatchString(%q) = %v, want %v", ref, got, want)
+		}
+	}
+}
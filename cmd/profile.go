@@ -1,8 +1,12 @@
 package cmd
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
+	"io/fs"
 	"net/http"
 	"os"
 
@@ -11,86 +15,114 @@ import (
 )
 
 type Profile struct {
-	Username string `json:"display_name"`
-	Email string `json:"email"`
+	Username     string `json:"display_name"`
+	Email        string `json:"email"`
 	ExternalURLs struct {
 		Spotify string `json:"spotify"`
 	} `json:"external_urls"`
 	Userid string `json:"id"`
-
-	
 }
 
-
-
-func getUserInfo() error {
+func getUserInfo(ctx context.Context) error {
 	var ProfileData Profile
 
-	url:= "https://api.spotify.com/v1/me"
-	client,err:=utils.NewSpotifyClient()
+	client, err := spotifyClient()
 
-	if err!=nil{
+	if err != nil {
 		return err
 	}
 
-	resp,err:=client.Get(url)
-	
-	if err!=nil{
+	resp, err := client.GetContext(ctx, client.APIURL("/me"))
+
+	if err != nil {
 		return err
 	}
 	defer resp.Body.Close()
-	
+
 	if resp.StatusCode == http.StatusUnauthorized {
-    	return fmt.Errorf("access token invalid or expired, please login again")
+		return fmt.Errorf("access token invalid or expired, please login again")
 	}
-	
+
+	if err := utils.CheckResponse(resp); err != nil {
+		return err
+	}
+
 	if err := json.NewDecoder(resp.Body).Decode(&ProfileData); err != nil { // reason using this is because io.readall will read everything into memory first and then do the struct here directly what is needed is added from struct val
 		return err
 	}
-	
-	file,err:=os.Create("profile.json")
-	
-	if err!=nil{
-		return fmt.Errorf("could not create json file for profile. Login again using login cmd")
+
+	return saveProfile(&ProfileData)
+}
+
+// loadProfile reads the profile cached by login for the active account
+func loadProfile() (*Profile, error) {
+	return loadAccountProfile(utils.ActiveAccount())
+}
+
+func loadAccountProfile(account string) (*Profile, error) {
+	path, err := utils.AccountFile(account, "profile.json")
+	if err != nil {
+		return nil, err
 	}
-	
-	defer file.Close()
 
-	encoder:=json.NewEncoder(file)
-	encoder.SetIndent(""," ")
-	
-	if err:=encoder.Encode(ProfileData);err!=nil{
-		return fmt.Errorf("Could not write the user info to the file")
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
 	}
 
-	return nil
+	var p Profile
+	if err := json.Unmarshal(data, &p); err != nil {
+		return nil, err
+	}
+	return &p, nil
 }
 
+func saveProfile(p *Profile) error {
+	path, err := utils.ProfilePath()
+	if err != nil {
+		return err
+	}
+
+	data, err := json.MarshalIndent(p, "", " ")
+	if err != nil {
+		return err
+	}
+
+	if err := utils.WriteFileAtomic(path, data, 0600); err != nil {
+		return fmt.Errorf("Could not write the user info to the file")
+	}
+	return nil
+}
 
 var profileCmd = &cobra.Command{
-	Use: "me",
+	Use:   "me",
 	Short: "fetch spotfiy user details",
-	RunE: func(cmd *cobra.Command, args []string) error{
-		var ProfileData Profile
-		
-		data, err := os.ReadFile("profile.json")
-		
-		if err != nil {
-		return fmt.Errorf("Profile not found please login again")
+	RunE: func(cmd *cobra.Command, args []string) error {
+		ProfileData, err := loadProfile()
+
+		if errors.Is(err, fs.ErrNotExist) {
+			return fmt.Errorf("Profile not found please login again")
 		}
-		
-		if err:=json.Unmarshal(data,&ProfileData);err!=nil{
+
+		if err != nil {
 			return fmt.Errorf("Couldnot fetch profile data from the json")
 		}
-		
-		fmt.Println("Username",ProfileData.Username)
-		fmt.Println("Email",ProfileData.Email)
-		fmt.Println("Link", ProfileData.ExternalURLs.Spotify)
-		
-		return nil
+
+		record := profileRecord{
+			ID:    ProfileData.Userid,
+			URI:   "spotify:user:" + ProfileData.Userid,
+			Name:  ProfileData.Username,
+			Email: ProfileData.Email,
+			URL:   ProfileData.ExternalURLs.Spotify,
+		}
+		return render(cmd, record, func(w io.Writer) {
+			fmt.Fprintln(w, "Username", ProfileData.Username)
+			fmt.Fprintln(w, "Email", ProfileData.Email)
+			fmt.Fprintln(w, "Link", ProfileData.ExternalURLs.Spotify)
+		})
 	},
 }
 
-func init(){
+func init() {
 	spotifyCmd.AddCommand(profileCmd)
-}
\ No newline at end of file
+}
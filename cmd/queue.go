@@ -0,0 +1,159 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/adi-253/Gitify/cmd/utils"
+	"github.com/spf13/cobra"
+)
+
+// QueueResponse represents the response from Spotify's /me/player/queue endpoint.
+// Episodes decode as tracks without artists or album.
+type QueueResponse struct {
+	CurrentlyPlaying *Track  `json:"currently_playing"`
+	Queue            []Track `json:"queue"`
+}
+
+// GetQueue fetches the playing item and the ones Spotify will play next, both the
+// tracks added to the queue and the rest of the playlist or album
+func GetQueue(ctx context.Context, client *utils.SpotifyClient) (*QueueResponse, error) {
+	resp, err := client.GetContext(ctx, client.APIURL("/me/player/queue"))
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if err := utils.CheckResponse(resp); err != nil {
+		return nil, err
+	}
+
+	var queue QueueResponse
+	if err := json.NewDecoder(resp.Body).Decode(&queue); err != nil {
+		return nil, err
+	}
+	return &queue, nil
+}
+
+// AddToQueue adds a track or episode URI to the end of the queue
+func AddToQueue(ctx context.Context, client *utils.SpotifyClient, uri string) error {
+	return playerCommand(ctx, client, http.MethodPost, "/me/player/queue?uri="+url.QueryEscape(uri), nil)
+}
+
+func queueRecords(queue *QueueResponse) []trackRecord {
+	var tracks []Track
+	if queue.CurrentlyPlaying != nil {
+		tracks = append(tracks, *queue.CurrentlyPlaying)
+	}
+	tracks = append(tracks, queue.Queue...)
+
+	// The playing item has no position, the upcoming ones count from 1
+	records := make([]trackRecord, len(tracks))
+	for i, t := range tracks {
+		position := i
+		if queue.CurrentlyPlaying == nil {
+			position = i + 1
+		}
+		artists := make([]string, len(t.Artists))
+		for j, a := range t.Artists {
+			artists[j] = a.Name
+		}
+		records[i] = newTrackRecord(position, t.ID, t.URI, t.Name, artists, t.Album.Name, t.DurationMS)
+	}
+	return records
+}
+
+var queueCmd = &cobra.Command{
+	Use:   "queue",
+	Short: "Show what plays next",
+	Long: `Show the playing track and what Spotify plays after it: tracks added with
+"gitify spotify queue add" first, then the rest of the playlist or album. With
+--output the playing track comes first, without a position.`,
+	Args:        cobra.NoArgs,
+	Annotations: needsScopes("user-read-playback-state"),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		client, err := spotifyClient()
+		if err != nil {
+			return err
+		}
+		queue, err := GetQueue(cmd.Context(), client)
+		if err != nil {
+			return errors.New(describeError(err))
+		}
+
+		return render(cmd, queueRecords(queue), func(w io.Writer) {
+			if queue.CurrentlyPlaying == nil {
+				fmt.Fprintln(w, "Nothing is playing")
+				return
+			}
+			fmt.Fprintf(w, "Now playing: %s — %s\n", queue.CurrentlyPlaying.Name, joinArtists(queue.CurrentlyPlaying.Artists))
+			if len(queue.Queue) == 0 {
+				fmt.Fprintln(w, "Nothing up next")
+				return
+			}
+			fmt.Fprintln(w, "\nUp next:")
+			for i, t := range queue.Queue {
+				fmt.Fprintf(w, "%2d. %s — %s\n", i+1, t.Name, joinArtists(t.Artists))
+			}
+		})
+	},
+}
+
+var queueAddCmd = &cobra.Command{
+	Use:   "add <uri|link|search terms>",
+	Short: "Add a track to the queue",
+	Long: `Add a track to the end of the queue, to play after the current one without
+replacing the playlist or album. Give a track or episode URI or open.spotify.com
+link, or search terms to queue the first search result:
+
+  gitify spotify queue add spotify:track:4uLU6hMCjMI75M1A2tKUQC
+  gitify spotify queue add never gonna give you up`,
+	Args:              cobra.MinimumNArgs(1),
+	ValidArgsFunction: completeTrackURIs,
+	Annotations:       needsScopes("user-modify-playback-state"),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		query := strings.Join(args, " ")
+		uri, kind, ok := parsePlayable(query)
+		if ok && kind != "track" && kind != "episode" {
+			return fmt.Errorf("only tracks and episodes can be queued; play the %s with `gitify spotify play %s`", kind, uri)
+		}
+
+		client, err := spotifyClient()
+		if err != nil {
+			return err
+		}
+
+		name := uri
+		if !ok {
+			items, err := searchTracks(cmd.Context(), client, query, 1)
+			if err != nil {
+				return err
+			}
+			if len(items) == 0 {
+				return fmt.Errorf("no results found for '%s'", query)
+			}
+			uri = items[0].URI
+			name = items[0].Name + " — " + strings.Join(items[0].artistNames(), ", ")
+		}
+
+		if err := AddToQueue(cmd.Context(), client, uri); err != nil {
+			return errors.New(describeError(err))
+		}
+
+		return render(cmd, playbackRecord{Action: "queue", URI: uri, Device: deviceOverride}, func(w io.Writer) {
+			fmt.Fprintf(w, "Added to queue: %s\n", name)
+		})
+	},
+}
+
+func init() {
+	addDeviceFlag(queueAddCmd)
+	queueCmd.AddCommand(queueAddCmd)
+	spotifyCmd.AddCommand(queueCmd)
+}
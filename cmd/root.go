@@ -4,8 +4,14 @@ Copyright © 2025 NAME HERE <EMAIL ADDRESS>
 package cmd
 
 import (
+	"context"
+	"errors"
+	"fmt"
 	"os"
+	"os/signal"
+	"syscall"
 
+	"github.com/adi-253/Gitify/cmd/utils"
 	"github.com/spf13/cobra"
 )
 
@@ -30,6 +36,8 @@ Examples:
   gitify spotify login          # Login via CLI
   gitify spotify me             # Show profile via CLI
   gitify spotify show playlist  # Show playlists via CLI`,
+	// Errors are already explained by the command; don't bury them under usage text
+	SilenceUsage: true,
 	Run: func(cmd *cobra.Command, args []string) {
 		// Show help when no subcommand is provided
 		cmd.Help()
@@ -37,11 +45,62 @@ Examples:
 }
 
 // Execute adds all child commands to the root command and sets flags appropriately.
-// This is called by main.main(). It only needs to happen once to the rootCmd.
-func Execute() {
-	err := rootCmd.Execute()
-	if err != nil {
-		os.Exit(1)
+// This is called by main.main(). It only needs to happen once to the rootCmd. It
+// returns the exit status: 128 plus the signal number when interrupted or terminated,
+// like an uncaught signal, 1 when the command failed.
+func Execute() int {
+	// Ctrl+C or SIGTERM cancels the command context so in-flight requests and
+	// pagination stop; the signal is kept as the cause for the exit status
+	ctx, cancel := context.WithCancelCause(context.Background())
+	defer cancel(nil)
+
+	sigs := make(chan os.Signal, 1)
+	signal.Notify(sigs, os.Interrupt, syscall.SIGTERM)
+	defer signal.Stop(sigs)
+	go func() {
+		select {
+		case sig := <-sigs:
+			cancel(signalError{sig})
+			// Commands blocked on a prompt never see the cancellation, so a second
+			// signal kills the process as usual
+			signal.Stop(sigs)
+		case <-ctx.Done():
+		}
+	}()
+
+	err := rootCmd.ExecuteContext(ctx)
+	var sigErr signalError
+	switch {
+	case errors.As(context.Cause(ctx), &sigErr):
+		return sigErr.exitCode()
+	case err != nil:
+		return 1
+	}
+	return 0
+}
+
+// signalError cancels the command context when a signal arrives
+type signalError struct {
+	sig os.Signal
+}
+
+func (e signalError) Error() string {
+	return "received " + e.sig.String()
+}
+
+// exitCode is what a shell reports for a process killed by the signal
+func (e signalError) exitCode() int {
+	if sig, ok := e.sig.(syscall.Signal); ok {
+		return 128 + int(sig)
+	}
+	return 130
+}
+
+// initConfig reads the config file once flags are parsed. A broken file only warns,
+// so `gitify config` can still be used to fix it.
+func initConfig() {
+	if err := utils.LoadConfig(); err != nil {
+		fmt.Fprintln(os.Stderr, "Warning:", err)
 	}
 }
 
@@ -50,11 +109,13 @@ func init() {
 	// Cobra supports persistent flags, which, if defined here,
 	// will be global for your application.
 
-	// rootCmd.PersistentFlags().StringVar(&cfgFile, "config", "", "config file (default is $HOME/.Gitify.yaml)")
+	cobra.OnInitialize(initConfig)
+
+	rootCmd.PersistentFlags().StringVar(&utils.ConfigFileOverride, "config", "", "config file (default $XDG_CONFIG_HOME/gitify/config.yaml, or $GITIFY_CONFIG)")
+
+	rootCmd.PersistentFlags().StringVar(&utils.ConfigDirOverride, "config-dir", "", "directory for tokens and profile (default $XDG_CONFIG_HOME/gitify, or $GITIFY_CONFIG_DIR)")
 
 	// Cobra also supports local flags, which will only run
 	// when this action is called directly.
 	rootCmd.Flags().BoolP("toggle", "t", false, "Help message for toggle")
 }
-
-
@@ -0,0 +1,61 @@
+package cmd
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/adi-253/Gitify/cmd/utils"
+	"github.com/spf13/cobra"
+)
+
+// scopesAnnotation lists, space separated, the OAuth scopes a command needs. Commands
+// set it with needsScopes and it is checked before they run.
+const scopesAnnotation = "gitify/scopes"
+
+// needsScopes returns command annotations declaring the scopes a command uses
+func needsScopes(scopes ...string) map[string]string {
+	return map[string]string{scopesAnnotation: strings.Join(scopes, " ")}
+}
+
+// ensureScopes checks the stored token was granted every scope cmd declares
+func ensureScopes(cmd *cobra.Command) error {
+	return requireScopes(cmd, strings.Fields(cmd.Annotations[scopesAnnotation])...)
+}
+
+// requireScopes checks the stored token was granted needed, for commands that only
+// need a scope on some paths. When one is missing it offers, on a terminal, to login
+// again asking for the old scopes plus the missing ones; otherwise it explains how to
+// grant them.
+func requireScopes(cmd *cobra.Command, needed ...string) error {
+	if len(needed) == 0 {
+		return nil
+	}
+
+	token, err := utils.StoredToken()
+	if err != nil {
+		// Not logged in (or unreadable): the command itself reports that
+		return nil
+	}
+	missing := token.MissingScopes(needed)
+	if len(missing) == 0 {
+		return nil
+	}
+
+	msg := fmt.Sprintf("`%s` needs Spotify permissions your login did not grant: %s", cmd.CommandPath(), strings.Join(missing, ", "))
+	if !stdinIsTerminal() {
+		return fmt.Errorf("%s\nRun `gitify spotify login --scope %s` to grant them", msg, strings.Join(missing, ","))
+	}
+
+	fmt.Fprintln(os.Stderr, msg)
+	fmt.Fprint(os.Stderr, "Login again now to grant them? [Y/n] ")
+	answer, _ := bufio.NewReader(os.Stdin).ReadString('\n')
+	if a := strings.ToLower(strings.TrimSpace(answer)); a != "" && a != "y" && a != "yes" {
+		return fmt.Errorf("%s", msg)
+	}
+
+	opts := defaultLoginOptions()
+	opts.scopes = missing
+	return runLogin(cmd, opts)
+}
@@ -1,9 +1,13 @@
 package cmd
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
 	"net/url"
+	"strconv"
 	"strings"
 
 	"github.com/adi-253/Gitify/cmd/utils"
@@ -18,12 +22,17 @@ type SearchResponse struct {
 }
 
 type TrackItem struct {
-	Name         string   `json:"name"`
+	Name         string       `json:"name"`
 	Artists      []ArtistResp `json:"artists"`
 	ExternalURLs struct {
 		Spotify string `json:"spotify"`
 	} `json:"external_urls"`
-	URI string `json:"uri"` // Used for playback
+	URI   string `json:"uri"` // Used for playback
+	ID    string `json:"id"`
+	Album struct {
+		Name string `json:"name"`
+	} `json:"album"`
+	DurationMS int `json:"duration_ms"`
 }
 
 type ArtistResp struct {
@@ -33,103 +42,174 @@ type ArtistResp struct {
 var searchcmd = &cobra.Command{
 	Use:   "search [song]",
 	Short: "Search for a song on Spotify",
-	Run: func(cmd *cobra.Command, args []string) {
+	Long: `Search for tracks. On a terminal, pick one of the results to play; --play n or
+--first play one without asking. When stdin is not a terminal, or --output is given,
+the results are printed without a prompt.`,
+	ValidArgsFunction: completeRecentSearches,
+	RunE: func(cmd *cobra.Command, args []string) error {
 		if len(args) == 0 {
-			fmt.Println("Type a song name to play")
-			return
+			return fmt.Errorf("type a song name to search for")
 		}
 
 		song := strings.Join(args, " ")
 
-		baseURL, err := url.Parse("https://api.spotify.com/v1/search")
+		client, err := spotifyClient()
 		if err != nil {
-			fmt.Println("Invalid base URL")
-			return
+			return err
 		}
 
-		params := url.Values{}
-		params.Add("q", song)
-		params.Add("type", "track")
-		params.Add("limit", "10")  // since the limit is 10 and each req returns 10 no need pagination
-
-		baseURL.RawQuery = params.Encode()
-
-		client, err := utils.NewSpotifyClient()
-		if err != nil {
-			fmt.Println("Relogin and try again")
-			return
+		// Flag beats the search_limit setting; one page is enough either way (max 50)
+		limit := utils.SearchLimit
+		if cmd.Flags().Changed("limit") {
+			limit, _ = cmd.Flags().GetInt("limit")
+		}
+		if limit < 1 || limit > 50 {
+			return fmt.Errorf("--limit must be between 1 and 50")
 		}
 
-		resp, err := client.Get(baseURL.String())
+		items, err := searchTracks(cmd.Context(), client, song, limit)
 		if err != nil {
-			fmt.Println("Error fetching data:", err)
-			return
+			return err
 		}
-		defer resp.Body.Close()
+		rememberTracks(searchRecords(items))
 
-		var result SearchResponse
-		if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
-			fmt.Println("Error parsing response:", err)
-			return
+		// --first is --play 1
+		play, _ := cmd.Flags().GetInt("play")
+		if first, _ := cmd.Flags().GetBool("first"); first {
+			play = 1
 		}
-
-		if len(result.Tracks.Items) == 0 {
-			fmt.Printf("No results found for '%s'\n", song)
-			return
+		if cmd.Flags().Changed("play") || play > 0 {
+			if len(items) == 0 {
+				return fmt.Errorf("no results found for '%s'", song)
+			}
+			if play < 1 || play > len(items) {
+				return fmt.Errorf("--play must be between 1 and %d", len(items))
+			}
+			return playSearchResult(cmd, items[play-1])
 		}
 
-		fmt.Printf("\nSearch Results for: %s\n", song)
-		fmt.Println(strings.Repeat("=", 40))
+		printResults := func(w io.Writer) {
+			if len(items) == 0 {
+				fmt.Fprintf(w, "No results found for '%s'\n", song)
+				return
+			}
+
+			fmt.Fprintf(w, "\nSearch Results for: %s\n", song)
+			fmt.Fprintln(w, strings.Repeat("=", 40))
 
-		for i, track := range result.Tracks.Items {
-			artistNames := make([]string, len(track.Artists))
-			for j, artist := range track.Artists {
-				artistNames[j] = artist.Name
+			for i, track := range items {
+				fmt.Fprintf(w, "%d. %s — %s\n", i+1, track.Name, strings.Join(track.artistNames(), ", "))
+				fmt.Fprintf(w, "   %s\n\n", track.ExternalURLs.Spotify)
 			}
+		}
 
-			fmt.Printf("%d. %s — %s\n", i+1, track.Name, strings.Join(artistNames, ", "))
-			fmt.Printf("   %s\n\n", track.ExternalURLs.Spotify)
+		// Scripts get the results without the prompt below
+		if !interactive() || len(items) == 0 {
+			return render(cmd, searchRecords(items), printResults)
 		}
+		printResults(cmd.OutOrStdout())
 
 		// Add playback options
 		fmt.Print("\nPlay Options:\n")
 		fmt.Println("Play track by number")
 		fmt.Println("[Q] Quit")
 		fmt.Print("\nChoose a track number to play (or Q to quit): ")
-		
+
 		var playChoice string
 		fmt.Scan(&playChoice)
-		
+
 		if strings.ToUpper(playChoice) == "Q" {
 			fmt.Println("Goodbye!")
-			return
+			return nil
 		}
-		
+
 		// Try to parse as track number
 		var trackNum int
 		if _, err := fmt.Sscanf(playChoice, "%d", &trackNum); err != nil {
-			fmt.Println("Invalid input. Please enter a number or Q.")
-			return
-		}
-		
-		if trackNum < 1 || trackNum > len(result.Tracks.Items) {
-			fmt.Printf("Invalid track number. Please enter 1-%d.\n", len(result.Tracks.Items))
-			return
+			return fmt.Errorf("invalid input, please enter a number or Q")
 		}
-		
-		// Play selected track
-		selectedTrack := result.Tracks.Items[trackNum-1]
-		trackURIs := []string{selectedTrack.URI}
-		artistNames := make([]string, len(selectedTrack.Artists))
-		for j, artist := range selectedTrack.Artists {
-			artistNames[j] = artist.Name
+
+		if trackNum < 1 || trackNum > len(items) {
+			return fmt.Errorf("invalid track number, please enter 1-%d", len(items))
 		}
-		
-		fmt.Printf("\n🎶 Playing: %s — %s\n", selectedTrack.Name, strings.Join(artistNames, ", "))
-		StartMusic(nil, &trackURIs)
+
+		return playSearchResult(cmd, items[trackNum-1])
 	},
 }
 
+// searchTracks returns up to limit tracks matching query
+func searchTracks(ctx context.Context, client *utils.SpotifyClient, query string, limit int) ([]TrackItem, error) {
+	baseURL, err := url.Parse(client.APIURL("/search"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid base URL")
+	}
+
+	params := url.Values{}
+	params.Add("q", query)
+	params.Add("type", "track")
+	params.Add("limit", strconv.Itoa(limit))
+	baseURL.RawQuery = params.Encode()
+
+	resp, err := client.GetContext(ctx, baseURL.String())
+	if err != nil {
+		return nil, fmt.Errorf("error fetching data: %s", describeError(err))
+	}
+	defer resp.Body.Close()
+
+	if err := utils.CheckResponse(resp); err != nil {
+		return nil, fmt.Errorf("error fetching data: %s", describeError(err))
+	}
+
+	var result SearchResponse
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return nil, fmt.Errorf("error parsing response: %v", err)
+	}
+	return result.Tracks.Items, nil
+}
+
+// playSearchResult starts playing one track from the results. Listing results needs
+// no scope, so the playback one is only asked for here.
+func playSearchResult(cmd *cobra.Command, track TrackItem) error {
+	if err := requireScopes(cmd, "user-modify-playback-state"); err != nil {
+		return err
+	}
+	client, err := spotifyClient()
+	if err != nil {
+		return err
+	}
+
+	trackURIs := []string{track.URI}
+	if err := StartMusic(cmd.Context(), client, nil, &trackURIs); err != nil {
+		return errors.New(describeError(err))
+	}
+
+	record := playbackRecord{Action: "play", URI: track.URI, Device: deviceOverride}
+	return render(cmd, record, func(w io.Writer) {
+		fmt.Fprintf(w, "🎶 Playing: %s — %s\n", track.Name, strings.Join(track.artistNames(), ", "))
+	})
+}
+
+func (t TrackItem) artistNames() []string {
+	names := make([]string, len(t.Artists))
+	for i, a := range t.Artists {
+		names[i] = a.Name
+	}
+	return names
+}
+
+func searchRecords(items []TrackItem) []trackRecord {
+	records := make([]trackRecord, len(items))
+	for i, t := range items {
+		records[i] = newTrackRecord(i+1, t.ID, t.URI, t.Name, t.artistNames(), t.Album.Name, t.DurationMS)
+	}
+	return records
+}
+
 func init() {
+	searchcmd.Flags().Int("limit", 10, "number of results, 1-50 (default from the search_limit setting)")
+	searchcmd.Flags().Int("play", 0, "play result number n instead of prompting")
+	searchcmd.Flags().Bool("first", false, "play the first result")
+	searchcmd.MarkFlagsMutuallyExclusive("play", "first")
+	addDeviceFlag(searchcmd)
 	spotifyCmd.AddCommand(searchcmd)
 }
@@ -0,0 +1,260 @@
+package cmd
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"html"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/adi-253/Gitify/cmd/utils"
+	"github.com/spf13/cobra"
+)
+
+// statusCache is the cache entry status bars share, so many of them polling at once
+// cost one request per cache TTL
+const statusCache = "status"
+
+// defaultStatusCacheTTL outlasts the usual one second between status bar polls
+const defaultStatusCacheTTL = 5 * time.Second
+
+// statusLockWait is how long status waits for another process's refresh when it has
+// no cached entry to show meanwhile
+const statusLockWait = 2 * time.Second
+
+// defaultStatusFormat is the status line's --format
+const defaultStatusFormat = "{{.Artist}} - {{.Track}}"
+
+// scrollGap separates the end of scrolling text from its start coming round again
+const scrollGap = "   "
+
+// cachedPlayback is the status cache entry. A failed lookup is cached too, so status
+// bars don't all retry it on every poll.
+type cachedPlayback struct {
+	Info      PlaybackInfo `json:"info"`
+	Error     string       `json:"error,omitempty"`
+	FetchedAt time.Time    `json:"fetched_at"`
+}
+
+// waybarOutput is a custom module's return-type json line, see waybar-custom(5)
+type waybarOutput struct {
+	Text       string `json:"text"`
+	Tooltip    string `json:"tooltip"`
+	Class      string `json:"class"`
+	Alt        string `json:"alt"`
+	Percentage int    `json:"percentage"`
+}
+
+// cachedPlaybackInfo returns the playback state, or the error looking it up, from the
+// status cache when it is younger than ttl, moving the progress on by the time since
+// it was fetched; or else fetches and caches it. Only one process refreshes the entry
+// at a time: the others keep serving the stale entry meanwhile, or, without one, wait
+// a little for the refresh to land.
+func cachedPlaybackInfo(cmd *cobra.Command, ttl time.Duration) (*PlaybackInfo, error) {
+	if ttl <= 0 {
+		// Without the cache every status bar just asks Spotify itself
+		return fetchPlaybackInfo(cmd)
+	}
+
+	var cached cachedPlayback
+	age, found := utils.ReadCacheAge(statusCache, &cached)
+	if found && age <= ttl {
+		return cached.playbackInfo()
+	}
+
+	unlock, locked := utils.LockCache(statusCache)
+	if locked {
+		defer unlock()
+	} else if found {
+		return cached.playbackInfo()
+	} else {
+		for deadline := time.Now().Add(statusLockWait); time.Now().Before(deadline); {
+			select {
+			case <-cmd.Context().Done():
+				return nil, cmd.Context().Err()
+			case <-time.After(50 * time.Millisecond):
+			}
+			if utils.ReadCache(statusCache, ttl, &cached) {
+				return cached.playbackInfo()
+			}
+		}
+		// The refresh is taking too long, so ask Spotify ourselves
+	}
+
+	info, err := fetchPlaybackInfo(cmd)
+	if cmd.Context().Err() != nil {
+		// Interrupted, which says nothing about Spotify
+		return nil, err
+	}
+	entry := cachedPlayback{FetchedAt: time.Now()}
+	if err != nil {
+		entry.Error = err.Error()
+	} else {
+		entry.Info = *info
+	}
+	_ = utils.WriteCache(statusCache, entry)
+	return info, err
+}
+
+// playbackInfo returns the cached state, or error, as of now
+func (c cachedPlayback) playbackInfo() (*PlaybackInfo, error) {
+	if c.Error != "" {
+		return nil, errors.New(c.Error)
+	}
+	info := c.Info
+	if info.IsPlaying {
+		info.ProgressMS = min(info.ProgressMS+int(time.Since(c.FetchedAt).Milliseconds()), info.DurationMS)
+	}
+	return &info, nil
+}
+
+func fetchPlaybackInfo(cmd *cobra.Command) (*PlaybackInfo, error) {
+	client, err := spotifyClient()
+	if err != nil {
+		return nil, err
+	}
+	info, err := GetCurrentPlayback(cmd.Context(), client)
+	if err != nil {
+		return nil, errors.New(describeError(err))
+	}
+	return info, nil
+}
+
+// fitStatus shortens text to at most maxLength characters, ending it with … or, when
+// scroll is set, showing a window onto it that moves one character a second
+func fitStatus(text string, maxLength int, scroll bool, now time.Time) string {
+	runes := []rune(text)
+	if maxLength <= 0 || len(runes) <= maxLength {
+		return text
+	}
+	if !scroll {
+		return string(runes[:maxLength-1]) + "…"
+	}
+
+	loop := append(runes, []rune(scrollGap)...)
+	start := int(now.Unix() % int64(len(loop)))
+	window := make([]rune, maxLength)
+	for i := range window {
+		window[i] = loop[(start+i)%len(loop)]
+	}
+	return string(window)
+}
+
+// waybarError is the --waybar line for a failed lookup: waybar shows a module's
+// stderr nowhere and hides it on a non-zero exit, so the error goes in the tooltip
+func waybarError(err error) (string, error) {
+	line, jsonErr := json.Marshal(waybarOutput{Tooltip: html.EscapeString(err.Error()), Class: "error", Alt: "error"})
+	return string(line), jsonErr
+}
+
+// statusLine renders one status line, "" when nothing is playing
+func statusLine(record nowRecord, tmpl *template.Template, maxLength int, scroll, waybar bool) (string, error) {
+	var text string
+	if record.State != "stopped" {
+		var b strings.Builder
+		if err := tmpl.Execute(&b, record); err != nil {
+			return "", err
+		}
+		text = fitStatus(strings.TrimSpace(b.String()), maxLength, scroll, time.Now())
+	}
+	if !waybar {
+		return text, nil
+	}
+
+	out := waybarOutput{Text: html.EscapeString(text), Class: record.State, Alt: record.State}
+	if record.State != "stopped" {
+		tooltip := record.Track + "\n" + record.Artist()
+		if record.Album != "" {
+			tooltip += "\n" + record.Album
+		}
+		out.Tooltip = html.EscapeString(tooltip)
+		if record.DurationMS > 0 {
+			out.Percentage = record.ProgressMS * 100 / record.DurationMS
+		}
+	}
+	line, err := json.Marshal(out)
+	return string(line), err
+}
+
+var statusCmd = &cobra.Command{
+	Use:   "status",
+	Short: "Print a one-line status for tmux, polybar, i3blocks or waybar",
+	Long: `Print the playing track as one line for a status bar, or an empty line when
+nothing is playing. --format is a Go template seeing the same fields as
+"gitify spotify now --format"; --max-length shortens the line, and --scroll makes a
+long line scroll by instead, one character a second.
+
+Status bars run this often, so the playback state is cached for the active account
+and shared by every status bar for --cache-ttl; while one of them refreshes it, the
+others keep showing the old state. --waybar prints the JSON a waybar
+custom module with "return-type": "json" expects, with the state as its class; when
+the lookup fails it prints a line with the class "error" and the error as tooltip.
+Failures are cached for --cache-ttl too.
+--watch keeps running and prints a line whenever the status changes, for bars
+that read a command's output continuously.
+
+  set -g status-right '#(gitify spotify status --max-length 40 --scroll)'
+  gitify spotify status --waybar --format '{{.Track}} · {{.Artist}}'`,
+	Args:        cobra.NoArgs,
+	Annotations: needsScopes("user-read-playback-state"),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		format, _ := cmd.Flags().GetString("format")
+		maxLength, _ := cmd.Flags().GetInt("max-length")
+		scroll, _ := cmd.Flags().GetBool("scroll")
+		waybar, _ := cmd.Flags().GetBool("waybar")
+		watch, _ := cmd.Flags().GetBool("watch")
+		ttl, _ := cmd.Flags().GetDuration("cache-ttl")
+
+		if structuredOutput() {
+			return errors.New("status prints its own format; use `gitify spotify now --output` for records")
+		}
+		if maxLength == 1 {
+			return errors.New("--max-length must be at least 2")
+		}
+		tmpl, err := parseFormat(format)
+		if err != nil {
+			return err
+		}
+
+		unattended()
+
+		frame := func() (string, error) {
+			info, err := cachedPlaybackInfo(cmd, ttl)
+			if err != nil && waybar && cmd.Context().Err() == nil {
+				line, err := waybarError(err)
+				return line + "\n", err
+			}
+			if err != nil {
+				return "", err
+			}
+			// The context name would cost another request, so it stays empty here
+			line, err := statusLine(newNowRecord(info, ""), tmpl, maxLength, scroll, waybar)
+			if err != nil {
+				return "", err
+			}
+			return line + "\n", nil
+		}
+
+		if watch {
+			return watchFrames(cmd, frame, false)
+		}
+		line, err := frame()
+		if err != nil {
+			return err
+		}
+		fmt.Fprint(cmd.OutOrStdout(), line)
+		return nil
+	},
+}
+
+func init() {
+	statusCmd.Flags().StringP("format", "f", defaultStatusFormat, "Go template for the line, see `gitify spotify now --help` for the fields")
+	statusCmd.Flags().Int("max-length", 0, "shorten the line to this many characters, 0 for no limit")
+	statusCmd.Flags().Bool("scroll", false, "scroll a line longer than --max-length instead of cutting it short")
+	statusCmd.Flags().Bool("waybar", false, "print JSON for a waybar custom module")
+	statusCmd.Flags().Duration("cache-ttl", defaultStatusCacheTTL, "how long status bars share one playback lookup, 0 to always ask Spotify")
+	statusCmd.Flags().BoolP("watch", "w", false, "keep running and print a line whenever the status changes")
+	spotifyCmd.AddCommand(statusCmd)
+}
@@ -0,0 +1,218 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/adi-253/Gitify/cmd/utils"
+	"github.com/spf13/cobra"
+)
+
+// parseSeekTarget reads a position such as "1:30", "1:02:03", "90" (seconds) or
+// "1m30s", or an offset from the current position such as "+10s" or "-1m"
+func parseSeekTarget(arg string) (ms int, relative bool, err error) {
+	invalid := fmt.Errorf("invalid position %q: use mm:ss, seconds, or an offset like +10s or -10s", arg)
+
+	if strings.HasPrefix(arg, "+") || strings.HasPrefix(arg, "-") {
+		relative = true
+	}
+
+	if n, err := strconv.Atoi(arg); err == nil {
+		return n * 1000, relative, nil
+	}
+	if d, err := time.ParseDuration(arg); err == nil {
+		return int(d.Milliseconds()), relative, nil
+	}
+	if relative || !strings.Contains(arg, ":") {
+		return 0, false, invalid
+	}
+
+	parts := strings.Split(arg, ":")
+	if len(parts) > 3 {
+		return 0, false, invalid
+	}
+	seconds := 0
+	for i, part := range parts {
+		n, err := strconv.Atoi(part)
+		// Every field but the first is two digits, below 60
+		if err != nil || n < 0 || (i > 0 && (len(part) != 2 || n >= 60)) {
+			return 0, false, invalid
+		}
+		seconds = seconds*60 + n
+	}
+	return seconds * 1000, false, nil
+}
+
+// parseVolume reads "0" to "100", or "+5" / "-5" to change the volume by that much
+func parseVolume(arg string) (value int, relative bool, err error) {
+	n, err := strconv.Atoi(arg)
+	if err != nil {
+		return 0, false, fmt.Errorf("invalid volume %q: use 0-100, or +n/-n to change it", arg)
+	}
+	if strings.HasPrefix(arg, "+") || strings.HasPrefix(arg, "-") {
+		return n, true, nil
+	}
+	if n > 100 {
+		return 0, false, fmt.Errorf("volume must be between 0 and 100")
+	}
+	return n, false, nil
+}
+
+// negativeArgHint explains a flag error caused by a negative offset, which the flag
+// parser takes for a flag
+func negativeArgHint(example string) func(cmd *cobra.Command, err error) error {
+	return func(cmd *cobra.Command, err error) error {
+		return fmt.Errorf("%v\nPut -- before a negative value, e.g. `%s -- %s`", err, cmd.CommandPath(), example)
+	}
+}
+
+var seekCmd = &cobra.Command{
+	Use:   "seek <position>",
+	Short: "Jump to a position in the current track",
+	Long: `Jump to a position in the current track, given as mm:ss, seconds or a duration
+like 1m30s. +10s and -10s move relative to the current position, so with --device
+only work on the device that is playing; put -- before a negative offset so it
+isn't taken for a flag:
+
+  gitify spotify seek 1:30
+  gitify spotify seek +15s
+  gitify spotify seek -- -10s`,
+	Args:        cobra.ExactArgs(1),
+	Annotations: needsScopes("user-read-playback-state", "user-modify-playback-state"),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		target, relative, err := parseSeekTarget(args[0])
+		if err != nil {
+			return err
+		}
+
+		client, err := spotifyClient()
+		if err != nil {
+			return err
+		}
+		if relative {
+			target, err = SeekBy(cmd.Context(), client, target)
+		} else {
+			err = Seek(cmd.Context(), client, target)
+		}
+		if err != nil {
+			return errors.New(describeError(err))
+		}
+
+		position := formatDuration(target)
+		return render(cmd, playbackRecord{Action: "seek", Value: position, Device: deviceOverride}, func(w io.Writer) {
+			fmt.Fprintf(w, "Jumped to %s\n", position)
+		})
+	},
+}
+
+var volumeCmd = &cobra.Command{
+	Use:   "volume <0-100|+n|-n>",
+	Short: "Set or change the playback volume",
+	Long: `Set the volume of the playing device, or the --device one, to a percentage, or
+change it with +n and -n. Put -- before a negative change so it isn't taken for a
+flag:
+
+  gitify spotify volume 40
+  gitify spotify volume +10
+  gitify spotify volume -- -10`,
+	Args:        cobra.ExactArgs(1),
+	Annotations: needsScopes("user-read-playback-state", "user-modify-playback-state"),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		volume, relative, err := parseVolume(args[0])
+		if err != nil {
+			return err
+		}
+
+		client, err := spotifyClient()
+		if err != nil {
+			return err
+		}
+		if relative {
+			volume, err = ChangeVolume(cmd.Context(), client, volume)
+		} else {
+			err = SetVolume(cmd.Context(), client, volume)
+		}
+		if err != nil {
+			return errors.New(describeError(err))
+		}
+
+		return render(cmd, playbackRecord{Action: "volume", Value: strconv.Itoa(volume), Device: deviceOverride}, func(w io.Writer) {
+			fmt.Fprintf(w, "Volume %d%%\n", volume)
+		})
+	},
+}
+
+var shuffleCmd = &cobra.Command{
+	Use:         "shuffle <on|off|toggle>",
+	Short:       "Turn shuffle on or off",
+	Args:        cobra.MatchAll(cobra.ExactArgs(1), cobra.OnlyValidArgs),
+	ValidArgs:   []string{"on", "off", "toggle"},
+	Annotations: needsScopes("user-read-playback-state", "user-modify-playback-state"),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		client, err := spotifyClient()
+		if err != nil {
+			return err
+		}
+
+		on := args[0] == "on"
+		if args[0] == "toggle" {
+			state, err := targetPlayerState(cmd.Context(), client)
+			if err != nil {
+				return errors.New(describeError(err))
+			}
+			if state == nil {
+				return errors.New(describeError(utils.ErrNoActiveDevice))
+			}
+			on = !state.ShuffleState
+		}
+
+		if err := SetShuffle(cmd.Context(), client, on); err != nil {
+			return errors.New(describeError(err))
+		}
+
+		value := "off"
+		if on {
+			value = "on"
+		}
+		return render(cmd, playbackRecord{Action: "shuffle", Value: value, Device: deviceOverride}, func(w io.Writer) {
+			fmt.Fprintf(w, "Shuffle %s\n", value)
+		})
+	},
+}
+
+var repeatCmd = &cobra.Command{
+	Use:   "repeat <off|track|context>",
+	Short: "Set the repeat mode",
+	Long: `Set the repeat mode: off, track to repeat the current track, or context to
+repeat the playlist or album.`,
+	Args:        cobra.MatchAll(cobra.ExactArgs(1), cobra.OnlyValidArgs),
+	ValidArgs:   repeatModes,
+	Annotations: needsScopes("user-modify-playback-state"),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		client, err := spotifyClient()
+		if err != nil {
+			return err
+		}
+		if err := SetRepeat(cmd.Context(), client, args[0]); err != nil {
+			return errors.New(describeError(err))
+		}
+
+		return render(cmd, playbackRecord{Action: "repeat", Value: args[0], Device: deviceOverride}, func(w io.Writer) {
+			fmt.Fprintf(w, "Repeat %s\n", args[0])
+		})
+	},
+}
+
+func init() {
+	seekCmd.SetFlagErrorFunc(negativeArgHint("-10s"))
+	volumeCmd.SetFlagErrorFunc(negativeArgHint("-10"))
+
+	for _, cmd := range []*cobra.Command{seekCmd, volumeCmd, shuffleCmd, repeatCmd} {
+		addDeviceFlag(cmd)
+		spotifyCmd.AddCommand(cmd)
+	}
+}
@@ -0,0 +1,107 @@
+package cmd
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestParseSeekTarget(t *testing.T) {
+	tests := []struct {
+		arg      string
+		ms       int
+		relative bool
+		wantErr  bool
+	}{
+		{arg: "90", ms: 90000},
+		{arg: "0", ms: 0},
+		{arg: "1:30", ms: 90000},
+		{arg: "0:05", ms: 5000},
+		{arg: "1:02:03", ms: 3723000},
+		{arg: "1m30s", ms: 90000},
+		{arg: "+10s", ms: 10000, relative: true},
+		{arg: "-10s", ms: -10000, relative: true},
+		{arg: "+15", ms: 15000, relative: true},
+		{arg: "-1m", ms: -60000, relative: true},
+		{arg: "1:5", wantErr: true},
+		{arg: "1:60", wantErr: true},
+		{arg: "1:2:3:4", wantErr: true},
+		{arg: "-1:30", wantErr: true},
+		{arg: "soon", wantErr: true},
+		{arg: "", wantErr: true},
+	}
+	for _, tt := range tests {
+		ms, relative, err := parseSeekTarget(tt.arg)
+		if tt.wantErr {
+			if err == nil {
+				t.Errorf("parseSeekTarget(%q) = %d, %v, want an error", tt.arg, ms, relative)
+			}
+			continue
+		}
+		if err != nil || ms != tt.ms || relative != tt.relative {
+			t.Errorf("parseSeekTarget(%q) = %d, %v, %v, want %d, %v", tt.arg, ms, relative, err, tt.ms, tt.relative)
+		}
+	}
+}
+
+func TestParseVolume(t *testing.T) {
+	tests := []struct {
+		arg      string
+		value    int
+		relative bool
+		wantErr  bool
+	}{
+		{arg: "0", value: 0},
+		{arg: "40", value: 40},
+		{arg: "100", value: 100},
+		{arg: "+5", value: 5, relative: true},
+		{arg: "-10", value: -10, relative: true},
+		// Relative changes are clamped once the current volume is known
+		{arg: "+150", value: 150, relative: true},
+		{arg: "101", wantErr: true},
+		{arg: "loud", wantErr: true},
+		{arg: "5%", wantErr: true},
+	}
+	for _, tt := range tests {
+		value, relative, err := parseVolume(tt.arg)
+		if tt.wantErr {
+			if err == nil {
+				t.Errorf("parseVolume(%q) = %d, %v, want an error", tt.arg, value, relative)
+			}
+			continue
+		}
+		if err != nil || value != tt.value || relative != tt.relative {
+			t.Errorf("parseVolume(%q) = %d, %v, %v, want %d, %v", tt.arg, value, relative, err, tt.value, tt.relative)
+		}
+	}
+}
+
+func TestTransportClampsAndNegativeArgs(t *testing.T) {
+	startFake(t)
+	if out, err := gitify(t, "spotify", "play", "spotify:track:track01"); err != nil {
+		t.Fatalf("play: %v\n%s", err, out)
+	}
+
+	tests := []struct {
+		args []string
+		want string
+	}{
+		{[]string{"volume", "+200"}, "Volume 100%"},
+		{[]string{"volume", "--", "-200"}, "Volume 0%"},
+		{[]string{"seek", "--", "-10m"}, "Jumped to 0:00"},
+		{[]string{"seek", "+1h"}, "Jumped to 3:20"},
+	}
+	for _, tt := range tests {
+		out, err := gitify(t, append([]string{"spotify"}, tt.args...)...)
+		if err != nil || !strings.Contains(out, tt.want) {
+			t.Errorf("%s: %v, printed %q, want %q", strings.Join(tt.args, " "), err, out, tt.want)
+		}
+	}
+
+	// Without -- a negative value is taken for a flag, and the error says what to do
+	for _, args := range [][]string{{"spotify", "seek", "-10s"}, {"spotify", "volume", "-10"}} {
+		_, err := gitify(t, args...)
+		if err == nil || !strings.Contains(err.Error(), "Put -- before a negative value") {
+			t.Errorf("%s: %v, want a hint to use --", strings.Join(args, " "), err)
+		}
+	}
+}
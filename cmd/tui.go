@@ -7,8 +7,11 @@ import (
 	"io"
 	"net/url"
 	"os"
+	"sort"
+	"strconv"
 	"strings"
 	"time"
+	"unicode/utf8"
 
 	"github.com/adi-253/Gitify/cmd/utils"
 	"github.com/charmbracelet/bubbles/key"
@@ -23,28 +26,83 @@ import (
 
 var (
 	// Color Palette - Spotify-inspired with modern aesthetics
-	spotifyGreen   = lipgloss.Color("#1DB954")
-	spotifyBlack   = lipgloss.Color("#121212")
-	spotifyDark    = lipgloss.Color("#181818")
-	spotifyGray    = lipgloss.Color("#282828")
-	spotifyLight   = lipgloss.Color("#B3B3B3")
-	accentPink     = lipgloss.Color("#E91E63")
+	spotifyGreen = lipgloss.Color("#1DB954")
+	spotifyBlack = lipgloss.Color("#121212")
+	spotifyDark  = lipgloss.Color("#181818")
+	spotifyGray  = lipgloss.Color("#282828")
+	spotifyLight = lipgloss.Color("#B3B3B3")
+	accentPink   = lipgloss.Color("#E91E63")
 	// accentPurple   = lipgloss.Color("#9B59B6")
-	accentCyan     = lipgloss.Color("#00BCD4")
+	accentCyan = lipgloss.Color("#00BCD4")
 	// accentOrange   = lipgloss.Color("#FF9800")
-	white          = lipgloss.Color("#FFFFFF")
-	subtleGray     = lipgloss.Color("#404040")
+	white      = lipgloss.Color("#FFFFFF")
+	subtleGray = lipgloss.Color("#404040")
 	// highlightGreen = lipgloss.Color("#1ED760")
 
 	// Gradient-like effect colors
 	// gradientStart = lipgloss.Color("#667eea")
 	// gradientEnd   = lipgloss.Color("#764ba2")
+)
+
+// palette is one color theme, selectable with the theme setting
+type palette struct {
+	accent, background, panel, panelAlt, text, alert, info, bright, subtle lipgloss.Color
+}
+
+var themes = map[string]palette{
+	"spotify": {"#1DB954", "#121212", "#181818", "#282828", "#B3B3B3", "#E91E63", "#00BCD4", "#FFFFFF", "#404040"},
+	"ocean":   {"#4FC3F7", "#0B1622", "#102030", "#1A2E42", "#A7C0D8", "#FF6F61", "#80CBC4", "#FFFFFF", "#34495E"},
+	"mono":    {"#E0E0E0", "#000000", "#111111", "#222222", "#AAAAAA", "#FFFFFF", "#CCCCCC", "#FFFFFF", "#555555"},
+}
+
+// themeNames lists the available themes, sorted
+func themeNames() []string {
+	var names []string
+	for name := range themes {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// applyTheme switches the colors to a theme and rebuilds the styles
+func applyTheme(name string) error {
+	p, ok := themes[name]
+	if !ok {
+		return fmt.Errorf("unknown theme %q, available: %s", name, strings.Join(themeNames(), ", "))
+	}
 
+	spotifyGreen, spotifyBlack, spotifyDark, spotifyGray = p.accent, p.background, p.panel, p.panelAlt
+	spotifyLight, accentPink, accentCyan, white, subtleGray = p.text, p.alert, p.info, p.bright, p.subtle
+	buildStyles()
+	return nil
+}
+
+// Styles, built from the colors above by buildStyles
+var (
+	sectionHeader           lipgloss.Style
+	logoStyle               lipgloss.Style
+	sidebarBoxStyle         lipgloss.Style
+	contentBoxStyle         lipgloss.Style
+	sidebarItemStyle        lipgloss.Style
+	sidebarItemFocusedStyle lipgloss.Style
+	helpStyle               lipgloss.Style
+	nowPlayingStyle         lipgloss.Style
+	dividerStyle            lipgloss.Style
+	userStyle               lipgloss.Style
+	accountStyle            lipgloss.Style
+	playingIndicatorStyle   lipgloss.Style
+	errorStyle              lipgloss.Style
+	successStyle            lipgloss.Style
+)
+
+// buildStyles (re)creates the styles from the current colors, after a theme changes them
+func buildStyles() {
 	// Main styles
 	sectionHeader = lipgloss.NewStyle().
-			Foreground(spotifyGreen).
-			Bold(true).
-			MarginBottom(1)
+		Foreground(spotifyGreen).
+		Bold(true).
+		MarginBottom(1)
 
 	// titleStyle = lipgloss.NewStyle().
 	// 		Foreground(spotifyGreen).
@@ -52,8 +110,8 @@ var (
 	// 		Padding(0, 1)
 
 	logoStyle = lipgloss.NewStyle().
-			Foreground(spotifyGreen).
-			Bold(true)
+		Foreground(spotifyGreen).
+		Bold(true)
 
 	// statusStyle = lipgloss.NewStyle().
 	// 		Foreground(spotifyLight).
@@ -63,16 +121,16 @@ var (
 
 	// Box styles with different themes
 	sidebarBoxStyle = lipgloss.NewStyle().
-			Border(lipgloss.RoundedBorder()).
-			BorderForeground(subtleGray).
-			Padding(1, 2).
-			Background(spotifyDark)
+		Border(lipgloss.RoundedBorder()).
+		BorderForeground(subtleGray).
+		Padding(1, 2).
+		Background(spotifyDark)
 
 	contentBoxStyle = lipgloss.NewStyle().
-			Border(lipgloss.RoundedBorder()).
-			BorderForeground(subtleGray).
-			Padding(1, 2).
-			Background(spotifyBlack)
+		Border(lipgloss.RoundedBorder()).
+		BorderForeground(subtleGray).
+		Padding(1, 2).
+		Background(spotifyBlack)
 
 	// Focused box style
 	// focusedBoxStyle = lipgloss.NewStyle().
@@ -83,8 +141,8 @@ var (
 
 	// Sidebar item styles
 	sidebarItemStyle = lipgloss.NewStyle().
-				Foreground(spotifyLight).
-				PaddingLeft(2)
+		Foreground(spotifyLight).
+		PaddingLeft(2)
 
 	// sidebarItemActiveStyle = lipgloss.NewStyle().
 	// 			Foreground(white).
@@ -94,25 +152,33 @@ var (
 	// 			PaddingRight(2)
 
 	sidebarItemFocusedStyle = lipgloss.NewStyle().
-				Foreground(spotifyBlack).
-				Background(spotifyGreen).
-				Bold(true).
-				PaddingLeft(2).
-				PaddingRight(2)
+		Foreground(spotifyBlack).
+		Background(spotifyGreen).
+		Bold(true).
+		PaddingLeft(2).
+		PaddingRight(2)
 
 	// Help text style
 	helpStyle = lipgloss.NewStyle().
-			Foreground(subtleGray).
-			Italic(true)
+		Foreground(subtleGray).
+		Italic(true)
 
 	// Now playing indicator
 	nowPlayingStyle = lipgloss.NewStyle().
-			Foreground(spotifyGreen).
-			Bold(true)
+		Foreground(spotifyGreen).
+		Bold(true)
 
 	// Divider style
 	dividerStyle = lipgloss.NewStyle().
-			Foreground(subtleGray)
+		Foreground(subtleGray)
+
+	// Logged in user and account shown under the logo
+	userStyle = lipgloss.NewStyle().
+		Foreground(white).
+		Bold(true)
+
+	accountStyle = lipgloss.NewStyle().
+		Foreground(accentCyan)
 
 	// Search input style
 	// searchBoxStyle = lipgloss.NewStyle().
@@ -131,20 +197,20 @@ var (
 
 	// Status indicators
 	playingIndicatorStyle = lipgloss.NewStyle().
-				Foreground(spotifyGreen).
-				Bold(true)
+		Foreground(spotifyGreen).
+		Bold(true)
 
 	errorStyle = lipgloss.NewStyle().
-			Foreground(accentPink).
-			Bold(true)
+		Foreground(accentPink).
+		Bold(true)
 
 	successStyle = lipgloss.NewStyle().
-			Foreground(spotifyGreen)
+		Foreground(spotifyGreen)
 
 	// Header decoration
 	// headerDecorStyle = lipgloss.NewStyle().
 	// 			Foreground(gradientStart)
-)
+}
 
 // ---------- Keymap ----------
 
@@ -159,6 +225,16 @@ type keyMap struct {
 	Pause     key.Binding
 	Next      key.Binding
 	Prev      key.Binding
+
+	VolumeUp    key.Binding
+	VolumeDown  key.Binding
+	SeekForward key.Binding
+	SeekBack    key.Binding
+	Shuffle     key.Binding
+	Repeat      key.Binding
+
+	AddToQueue key.Binding
+	Queue      key.Binding
 }
 
 func defaultKeyMap() keyMap {
@@ -203,6 +279,113 @@ func defaultKeyMap() keyMap {
 			key.WithKeys("h", "left"),
 			key.WithHelp("←/h", "prev track"),
 		),
+		VolumeUp: key.NewBinding(
+			key.WithKeys("+", "="),
+			key.WithHelp("+", "volume up"),
+		),
+		VolumeDown: key.NewBinding(
+			key.WithKeys("-"),
+			key.WithHelp("-", "volume down"),
+		),
+		SeekForward: key.NewBinding(
+			key.WithKeys(">", "."),
+			key.WithHelp(">", "seek forward"),
+		),
+		SeekBack: key.NewBinding(
+			key.WithKeys("<", ","),
+			key.WithHelp("<", "seek back"),
+		),
+		Shuffle: key.NewBinding(
+			key.WithKeys("z"),
+			key.WithHelp("z", "shuffle"),
+		),
+		Repeat: key.NewBinding(
+			key.WithKeys("r"),
+			key.WithHelp("r", "repeat"),
+		),
+		AddToQueue: key.NewBinding(
+			key.WithKeys("a"),
+			key.WithHelp("a", "add to queue"),
+		),
+		Queue: key.NewBinding(
+			key.WithKeys("u"),
+			key.WithHelp("u", "focus queue"),
+		),
+	}
+}
+
+// sidebarKey is the first of the keys a binding's help lists, e.g. "←" for "←/h", to
+// keep the sidebar narrow
+func sidebarKey(b key.Binding) string {
+	k := b.Help().Key
+	_, first := utf8.DecodeRuneInString(k)
+	if i := strings.IndexAny(k[first:], "/,"); i >= 0 {
+		return k[:first+i]
+	}
+	return k
+}
+
+// How far the volume and seek keys move
+const (
+	volumeStep = 5
+	seekStep   = 10 * time.Second
+)
+
+// actionRefreshDelay gives Spotify time to apply a key press before playback is re-read
+const actionRefreshDelay = 500 * time.Millisecond
+
+// bindings maps the action names used by the keybindings.<action> settings to the bindings
+func (k *keyMap) bindings() map[string]*key.Binding {
+	return map[string]*key.Binding{
+		"quit":      &k.Quit,
+		"help":      &k.Help,
+		"next_pane": &k.NextPane,
+		"prev_pane": &k.PrevPane,
+		"search":    &k.Search,
+		"playlists": &k.Playlists,
+		"play":      &k.Play,
+		"pause":     &k.Pause,
+		"next":      &k.Next,
+		"prev":      &k.Prev,
+
+		"volume_up":    &k.VolumeUp,
+		"volume_down":  &k.VolumeDown,
+		"seek_forward": &k.SeekForward,
+		"seek_back":    &k.SeekBack,
+		"shuffle":      &k.Shuffle,
+		"repeat":       &k.Repeat,
+
+		"add_to_queue": &k.AddToQueue,
+		"queue":        &k.Queue,
+	}
+}
+
+// applyKeybindings replaces the keys of each configured action. Keys are comma
+// separated; "space" stands for the space bar. Unknown actions are ignored.
+func (k *keyMap) applyKeybindings(overrides map[string]string) {
+	bindings := k.bindings()
+	for action, value := range overrides {
+		binding, ok := bindings[action]
+		if !ok {
+			continue
+		}
+
+		var keys []string
+		for _, key := range strings.Split(value, ",") {
+			key = strings.TrimSpace(key)
+			if key == "space" {
+				key = " "
+			}
+			if key != "" {
+				keys = append(keys, key)
+			}
+		}
+		if len(keys) == 0 {
+			continue
+		}
+
+		binding.SetKeys(keys...)
+		binding.SetHelp(strings.ReplaceAll(value, " ", ""), binding.Help().Desc)
 	}
 }
 
@@ -216,12 +399,13 @@ const (
 	focusTracks
 	focusSearch
 	focusSearchResults
+	focusQueue
 )
 
 type trackRow struct {
 	title  string
 	sub    string
-	isFrom string // "playlist" or "search"
+	isFrom string // "playlist", "search" or "queue"
 	index  int
 }
 
@@ -317,6 +501,13 @@ type tuiModel struct {
 	width  int
 	height int
 
+	// ctx is cancelled when the TUI quits so in-flight requests stop
+	ctx    context.Context
+	cancel context.CancelFunc
+
+	// client is nil when there is no saved login
+	client *utils.SpotifyClient
+
 	keys   keyMap
 	status string
 
@@ -325,12 +516,14 @@ type tuiModel struct {
 	// login/profile
 	isLoggedIn  bool
 	userProfile *Profile
+	account     string // named account the token belongs to
 
 	// data
-	playlists     []Playlist
+	playlists          []Playlist
 	currentPlaylistIdx int
-	currentTracks  []PlaylistTrack
-	searchTracks   []TrackItem
+	currentTracks      []Track
+	searchTracks       []TrackItem
+	queue              *QueueResponse // nil until the queue pane first loads
 
 	// UI components
 	sidebarSections []string
@@ -339,11 +532,19 @@ type tuiModel struct {
 	trackList       list.Model
 	searchInput     textinput.Model
 	searchList      list.Model
+	queueList       list.Model
 
 	// playback
 	isPlaying       bool
 	currentTrackURI string
 	lastActionAt    time.Time
+	volume          int // -1 until known, or without volume control
+	shuffle         bool
+	repeat          string
+	// a playback fetch is running; refetch asks for another once it is back, so
+	// fetches never overlap and arrive in order
+	fetching bool
+	refetch  bool
 
 	loading bool
 	errMsg  string
@@ -359,7 +560,7 @@ type playlistsLoadedMsg struct {
 
 type tracksLoadedMsg struct {
 	playlistIdx int
-	tracks      []PlaylistTrack
+	tracks      []Track
 }
 
 type searchResultsMsg struct {
@@ -376,9 +577,28 @@ type playbackUpdatedMsg struct {
 	info *PlaybackInfo
 }
 
+type queueLoadedMsg struct {
+	queue *QueueResponse
+}
+
+type playbackErrMsg struct {
+	err error
+}
+
+// pollTickMsg asks for a playback refresh every utils.PollInterval
+type pollTickMsg struct{}
+
+// actionRefreshMsg asks for a playback refresh shortly after a key press
+type actionRefreshMsg struct{}
+
 // ---------- Init helpers ----------
 
-func initialModel() tuiModel {
+func initialModel(parent context.Context, client *utils.SpotifyClient) tuiModel {
+	ctx, cancel := context.WithCancel(parent)
+
+	keys := defaultKeyMap()
+	keys.applyKeybindings(utils.Keybindings)
+
 	ti := textinput.New()
 	ti.Placeholder = "🔍 Search for tracks, artists, albums..."
 	ti.Focus()
@@ -389,7 +609,7 @@ func initialModel() tuiModel {
 	ti.PlaceholderStyle = lipgloss.NewStyle().Foreground(subtleGray).Italic(true)
 	ti.Cursor.Style = lipgloss.NewStyle().Foreground(spotifyGreen)
 
-	sidebar := []string{"🎵 Now Playing", "📁 Playlists", "🔍 Search"}
+	sidebar := []string{"🎵 Now Playing", "📁 Playlists", "🔍 Search", "📜 Queue"}
 
 	// Custom delegate for playlists (no description, playlist icons)
 	playlistDelegate := newCustomDelegate(false, true)
@@ -429,8 +649,24 @@ func initialModel() tuiModel {
 		Bold(true).
 		MarginBottom(1)
 
+	// Custom delegate for the queue (with description)
+	queueDelegate := newCustomDelegate(true, false)
+	queueList := list.New(nil, queueDelegate, 0, 0)
+	queueList.Title = "📜 Up Next"
+	queueList.SetShowStatusBar(false)
+	queueList.SetFilteringEnabled(false)
+	queueList.SetShowHelp(false)
+	queueList.Styles.Title = lipgloss.NewStyle().
+		Foreground(spotifyGreen).
+		Bold(true).
+		MarginBottom(1)
+
 	return tuiModel{
-		keys:            defaultKeyMap(),
+		ctx:             ctx,
+		cancel:          cancel,
+		client:          client,
+		account:         utils.ActiveAccount(),
+		keys:            keys,
 		status:          "✨ Welcome to Gitify TUI · Loading profile…",
 		focus:           focusSidebar,
 		sidebarSections: sidebar,
@@ -438,105 +674,71 @@ func initialModel() tuiModel {
 		trackList:       trackList,
 		searchInput:     ti,
 		searchList:      searchList,
+		queueList:       queueList,
 		lastActionAt:    time.Now(),
+		volume:          -1,
 	}
 }
 
 // ---------- Async loaders ----------
 
-func loadProfileCmd() tea.Cmd {
+func loadProfileCmd(client *utils.SpotifyClient) tea.Cmd {
 	return func() tea.Msg {
-		// Check if user is logged in by looking for token.json
-		if _, err := os.Stat("token.json"); err != nil {
+		// Without a client there was no usable token
+		if client == nil {
 			return profileLoadedMsg{profile: nil, logged: false}
 		}
-		data, err := os.ReadFile("profile.json")
+		p, err := loadProfile()
 		if err != nil {
 			return profileLoadedMsg{profile: nil, logged: true}
 		}
-		var p Profile
-		if json.Unmarshal(data, &p) != nil {
-			return profileLoadedMsg{profile: nil, logged: true}
-		}
-		return profileLoadedMsg{profile: &p, logged: true}
+		return profileLoadedMsg{profile: p, logged: true}
 	}
 }
 
-func loadPlaylistsCmd(userID string) tea.Cmd {
+func loadPlaylistsCmd(ctx context.Context, client *utils.SpotifyClient, userID string) tea.Cmd {
 	return func() tea.Msg {
-		client, err := utils.NewSpotifyClient()
+		playlists, err := fetchAllPlaylists(ctx, client, client.APIURL("/users/"+userID+"/playlists"))
 		if err != nil {
 			return errMsg(err)
 		}
-
-		urlStr := "https://api.spotify.com/v1/users/" + userID + "/playlists"
-		resp, err := client.Get(urlStr)
-		if err != nil {
-			return errMsg(err)
-		}
-		defer resp.Body.Close()
-
-		var playlistsResp PlaylistsResponse
-		if err := json.NewDecoder(resp.Body).Decode(&playlistsResp); err != nil {
-			return errMsg(err)
-		}
-
-		return playlistsLoadedMsg{playlists: playlistsResp.Items}
+		saveForCompletion(playlistsCache, playlists)
+		return playlistsLoadedMsg{playlists: playlists}
 	}
 }
 
-func loadTracksCmd(p Playlist, idx int) tea.Cmd {
+func loadTracksCmd(ctx context.Context, client *utils.SpotifyClient, p Playlist, idx int) tea.Cmd {
 	return func() tea.Msg {
-		client, err := utils.NewSpotifyClient()
+		tracks, err := fetchAllTracks(ctx, client, p.Tracks.Href)
 		if err != nil {
 			return errMsg(err)
 		}
-
-		var all []PlaylistTrack
-		next := p.Tracks.Href
-
-		for next != "" {
-			resp, err := client.Get(next)
-		if err != nil {
-				return errMsg(err)
-		}
-		defer resp.Body.Close()
-		
-			var res PlaylistTracksResponse
-			if err := json.NewDecoder(resp.Body).Decode(&res); err != nil {
-				return errMsg(err)
-			}
-			all = append(all, res.Items...)
-			next = res.Next
-		}
-
-		return tracksLoadedMsg{playlistIdx: idx, tracks: all}
+		return tracksLoadedMsg{playlistIdx: idx, tracks: tracks}
 	}
 }
 
-func searchCmd(query string) tea.Cmd {
+func searchCmd(ctx context.Context, client *utils.SpotifyClient, query string) tea.Cmd {
 	return func() tea.Msg {
-		client, err := utils.NewSpotifyClient()
-		if err != nil {
-			return errMsg(err)
-		}
-		
-		baseURL, err := url.Parse("https://api.spotify.com/v1/search")
+		baseURL, err := url.Parse(client.APIURL("/search"))
 		if err != nil {
 			return errMsg(err)
 		}
 		params := url.Values{}
 		params.Add("q", query)
 		params.Add("type", "track")
-		params.Add("limit", "30")
+		params.Add("limit", strconv.Itoa(utils.SearchLimit))
 		baseURL.RawQuery = params.Encode()
-		
-		resp, err := client.Get(baseURL.String())
+
+		resp, err := client.GetContext(ctx, baseURL.String())
 		if err != nil {
 			return errMsg(err)
 		}
 		defer resp.Body.Close()
-		
+
+		if err := utils.CheckResponse(resp); err != nil {
+			return errMsg(err)
+		}
+
 		var result SearchResponse
 		if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
 			return errMsg(err)
@@ -546,22 +748,88 @@ func searchCmd(query string) tea.Cmd {
 	}
 }
 
-func fetchPlaybackCmd() tea.Cmd {
-	return tea.Tick(time.Millisecond*500, func(t time.Time) tea.Msg {
-		info, err := GetCurrentPlayback()
+// fetchPlaybackCmd always answers with a playbackUpdatedMsg, info nil on failure, so
+// the model knows the fetch is over
+func fetchPlaybackCmd(ctx context.Context, client *utils.SpotifyClient) tea.Cmd {
+	return func() tea.Msg {
+		if client == nil {
+			return playbackUpdatedMsg{}
+		}
+		info, err := GetCurrentPlayback(ctx, client)
 		if err != nil {
-			return nil // silently ignore errors
+			return playbackUpdatedMsg{} // silently ignore errors
 		}
 		return playbackUpdatedMsg{info: info}
-	})
+	}
+}
+
+func actionRefreshCmd() tea.Cmd {
+	return tea.Tick(actionRefreshDelay, func(time.Time) tea.Msg { return actionRefreshMsg{} })
+}
+
+// fetchPlayback starts a playback fetch unless one is already running
+func (m *tuiModel) fetchPlayback() tea.Cmd {
+	if m.fetching {
+		return nil
+	}
+	m.fetching = true
+	return fetchPlaybackCmd(m.ctx, m.client)
+}
+
+func pollTickCmd() tea.Cmd {
+	return tea.Tick(utils.PollInterval, func(time.Time) tea.Msg { return pollTickMsg{} })
+}
+
+func loadQueueCmd(ctx context.Context, client *utils.SpotifyClient) tea.Cmd {
+	return func() tea.Msg {
+		if client == nil {
+			return errMsg(utils.ErrUnauthorized)
+		}
+		queue, err := GetQueue(ctx, client)
+		if err != nil {
+			return errMsg(err)
+		}
+		return queueLoadedMsg{queue: queue}
+	}
+}
+
+// addToQueueCmd queues a track, then reloads the queue so the pane shows it
+func addToQueueCmd(ctx context.Context, client *utils.SpotifyClient, uri string) tea.Cmd {
+	return func() tea.Msg {
+		if client == nil {
+			return playbackErrMsg{err: utils.ErrUnauthorized}
+		}
+		if err := AddToQueue(ctx, client, uri); err != nil {
+			return playbackErrMsg{err: err}
+		}
+		queue, err := GetQueue(ctx, client)
+		if err != nil {
+			return nil // the next poll picks it up
+		}
+		return queueLoadedMsg{queue: queue}
+	}
+}
+
+// playbackActionCmd runs a playback helper off the UI goroutine and reports failures
+func playbackActionCmd(ctx context.Context, client *utils.SpotifyClient, action func(ctx context.Context, client *utils.SpotifyClient) error) tea.Cmd {
+	return func() tea.Msg {
+		if client == nil {
+			return playbackErrMsg{err: utils.ErrUnauthorized}
+		}
+		if err := action(ctx, client); err != nil {
+			return playbackErrMsg{err: err}
+		}
+		return nil
+	}
 }
 
 // ---------- Bubble Tea interface ----------
 
 func (m tuiModel) Init() tea.Cmd {
 	return tea.Batch(
-		loadProfileCmd(),
+		loadProfileCmd(m.client),
 		tea.ClearScreen,
+		pollTickCmd(),
 	)
 }
 
@@ -579,14 +847,15 @@ func (m tuiModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		} else {
 			if m.userProfile != nil {
 				m.status = fmt.Sprintf("👋 Hello, %s · Loading playlists…", m.userProfile.Username)
-				cmds = append(cmds, loadPlaylistsCmd(m.userProfile.Userid))
+				cmds = append(cmds, loadPlaylistsCmd(m.ctx, m.client, m.userProfile.Userid))
 			} else {
 				m.status = "✅ Logged in · Loading playlists…"
 				// fallback user info
-				cmds = append(cmds, loadPlaylistsCmd("me"))
+				cmds = append(cmds, loadPlaylistsCmd(m.ctx, m.client, "me"))
 			}
 		}
 	case playlistsLoadedMsg:
+		m.errMsg = ""
 		m.playlists = msg.playlists
 		items := make([]list.Item, len(msg.playlists))
 		for i, p := range msg.playlists {
@@ -602,13 +871,14 @@ func (m tuiModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		if msg.playlistIdx < 0 || msg.playlistIdx >= len(m.playlists) {
 			break
 		}
+		m.errMsg = ""
 		m.currentPlaylistIdx = msg.playlistIdx
 		m.currentTracks = msg.tracks
 		items := make([]list.Item, 0, len(msg.tracks))
 		for i, t := range msg.tracks {
-			sub := joinArtists(t.Track.Artists)
+			sub := joinArtists(t.Artists)
 			items = append(items, trackRow{
-				title:  t.Track.Name,
+				title:  t.Name,
 				sub:    sub,
 				isFrom: "playlist",
 				index:  i,
@@ -622,6 +892,7 @@ func (m tuiModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		}
 		m.focus = focusTracks
 	case searchResultsMsg:
+		m.errMsg = ""
 		m.searchTracks = msg.tracks
 		items := make([]list.Item, 0, len(msg.tracks))
 		for i, t := range msg.tracks {
@@ -640,12 +911,50 @@ func (m tuiModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			m.status = fmt.Sprintf("🔍 Found %d tracks for %q", len(items), msg.query)
 		}
 		m.focus = focusSearchResults
+	case queueLoadedMsg:
+		m.queue = msg.queue
+		items := make([]list.Item, 0, len(msg.queue.Queue))
+		for i, t := range msg.queue.Queue {
+			items = append(items, trackRow{
+				title:  t.Name,
+				sub:    joinArtists(t.Artists),
+				isFrom: "queue",
+				index:  i,
+			})
+		}
+		m.queueList.SetItems(items)
 	case errMsg:
-		m.errMsg = msg.Error()
-		m.status = "❌ Error: " + msg.Error()
+		m.errMsg = describeError(msg)
+		m.status = "❌ Error: " + m.errMsg
+	case playbackErrMsg:
+		m.errMsg = describeError(msg.err)
+		m.status = "❌ " + m.errMsg
+	case pollTickMsg:
+		// A tick that finds the last fetch still running is skipped
+		fetch := m.fetchPlayback()
+		// The queue only changes on screen while it is shown
+		if m.focus == focusQueue {
+			return m, tea.Batch(fetch, loadQueueCmd(m.ctx, m.client), pollTickCmd())
+		}
+		return m, tea.Batch(fetch, pollTickCmd())
+	case actionRefreshMsg:
+		// A fetch already running may predate the action, so read again after it
+		if m.fetching {
+			m.refetch = true
+			return m, nil
+		}
+		return m, m.fetchPlayback()
 	case playbackUpdatedMsg:
+		m.fetching = false
+		if m.refetch {
+			m.refetch = false
+			cmds = append(cmds, m.fetchPlayback())
+		}
 		if msg.info != nil {
 			m.isPlaying = msg.info.IsPlaying
+			m.volume = msg.info.Volume
+			m.shuffle = msg.info.Shuffle
+			m.repeat = msg.info.Repeat
 			if msg.info.TrackName != "" {
 				m.currentTrackURI = msg.info.TrackURI
 				if m.isPlaying {
@@ -657,6 +966,7 @@ func (m tuiModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		}
 	case tea.KeyMsg:
 		if key.Matches(msg, m.keys.Quit) {
+			m.cancel()
 			return m, tea.Quit
 		}
 
@@ -692,29 +1002,79 @@ func (m tuiModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			return m, nil
 		}
 
+		if key.Matches(msg, m.keys.Queue) {
+			m.focus = focusQueue
+			return m, loadQueueCmd(m.ctx, m.client)
+		}
+
 		switch {
 		case key.Matches(msg, m.keys.Pause):
+			var action tea.Cmd
 			if m.isPlaying {
-				go PausePlayback()
+				action = playbackActionCmd(m.ctx, m.client, PausePlayback)
 				m.isPlaying = false
 				m.status = "⏸ Paused"
 			} else {
-				go ResumePlayback()
+				action = playbackActionCmd(m.ctx, m.client, ResumePlayback)
 				m.isPlaying = true
 				m.status = "▶ Resumed"
 			}
+			m.errMsg = ""
 			m.lastActionAt = time.Now()
-			return m, fetchPlaybackCmd()
+			return m, tea.Batch(action, actionRefreshCmd())
 		case key.Matches(msg, m.keys.Next):
-			go NextTrack()
+			m.errMsg = ""
 			m.lastActionAt = time.Now()
 			m.status = "⏭ Skipping to next..."
-			return m, fetchPlaybackCmd()
+			return m, tea.Batch(playbackActionCmd(m.ctx, m.client, NextTrack), actionRefreshCmd())
 		case key.Matches(msg, m.keys.Prev):
-			go PreviousTrack()
+			m.errMsg = ""
 			m.lastActionAt = time.Now()
 			m.status = "⏮ Going to previous..."
-			return m, fetchPlaybackCmd()
+			return m, tea.Batch(playbackActionCmd(m.ctx, m.client, PreviousTrack), actionRefreshCmd())
+		case key.Matches(msg, m.keys.VolumeUp), key.Matches(msg, m.keys.VolumeDown):
+			delta := volumeStep
+			if key.Matches(msg, m.keys.VolumeDown) {
+				delta = -volumeStep
+			}
+			if m.volume >= 0 {
+				m.volume = min(max(m.volume+delta, 0), 100)
+			}
+			m.errMsg = ""
+			m.lastActionAt = time.Now()
+			return m, tea.Batch(playbackActionCmd(m.ctx, m.client, func(ctx context.Context, client *utils.SpotifyClient) error {
+				_, err := ChangeVolume(ctx, client, delta)
+				return err
+			}), actionRefreshCmd())
+		case key.Matches(msg, m.keys.SeekForward), key.Matches(msg, m.keys.SeekBack):
+			delta := int(seekStep.Milliseconds())
+			m.status = "⏩ Seeking forward..."
+			if key.Matches(msg, m.keys.SeekBack) {
+				delta = -delta
+				m.status = "⏪ Seeking back..."
+			}
+			m.errMsg = ""
+			m.lastActionAt = time.Now()
+			return m, tea.Batch(playbackActionCmd(m.ctx, m.client, func(ctx context.Context, client *utils.SpotifyClient) error {
+				_, err := SeekBy(ctx, client, delta)
+				return err
+			}), actionRefreshCmd())
+		case key.Matches(msg, m.keys.Shuffle):
+			m.shuffle = !m.shuffle
+			on := m.shuffle
+			m.errMsg = ""
+			m.lastActionAt = time.Now()
+			return m, tea.Batch(playbackActionCmd(m.ctx, m.client, func(ctx context.Context, client *utils.SpotifyClient) error {
+				return SetShuffle(ctx, client, on)
+			}), actionRefreshCmd())
+		case key.Matches(msg, m.keys.Repeat):
+			m.repeat = nextRepeatMode(m.repeat)
+			mode := m.repeat
+			m.errMsg = ""
+			m.lastActionAt = time.Now()
+			return m, tea.Batch(playbackActionCmd(m.ctx, m.client, func(ctx context.Context, client *utils.SpotifyClient) error {
+				return SetRepeat(ctx, client, mode)
+			}), actionRefreshCmd())
 		}
 	}
 
@@ -730,7 +1090,7 @@ func (m tuiModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			idx := m.playlistList.Index()
 			if idx >= 0 && idx < len(m.playlists) {
 				m.status = "⏳ Loading tracks…"
-				cmds = append(cmds, loadTracksCmd(m.playlists[idx], idx))
+				cmds = append(cmds, loadTracksCmd(m.ctx, m.client, m.playlists[idx], idx))
 			}
 		}
 		cmds = append(cmds, cmd)
@@ -738,7 +1098,9 @@ func (m tuiModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		var cmd tea.Cmd
 		m.trackList, cmd = m.trackList.Update(msg)
 		if km, ok := msg.(tea.KeyMsg); ok && key.Matches(km, m.keys.Play) {
-			m.playSelectedTrackFromList()
+			cmds = append(cmds, m.playSelectedTrackFromList())
+		} else if ok && key.Matches(km, m.keys.AddToQueue) {
+			cmds = append(cmds, m.queueSelectedTrackFromList())
 		}
 		cmds = append(cmds, cmd)
 	case focusSearch:
@@ -749,7 +1111,7 @@ func (m tuiModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 				q := strings.TrimSpace(m.searchInput.Value())
 				if q != "" {
 					m.status = "🔍 Searching…"
-					cmds = append(cmds, searchCmd(q))
+					cmds = append(cmds, searchCmd(m.ctx, m.client, q))
 				}
 			} else if km.Type == tea.KeyDown && len(m.searchTracks) > 0 {
 				// Down arrow moves to search results if available
@@ -763,9 +1125,15 @@ func (m tuiModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		var cmd tea.Cmd
 		m.searchList, cmd = m.searchList.Update(msg)
 		if km, ok := msg.(tea.KeyMsg); ok && key.Matches(km, m.keys.Play) {
-			m.playSelectedSearchTrackFromList()
+			cmds = append(cmds, m.playSelectedSearchTrackFromList())
+		} else if ok && key.Matches(km, m.keys.AddToQueue) {
+			cmds = append(cmds, m.queueSelectedSearchTrackFromList())
 		}
 		cmds = append(cmds, cmd)
+	case focusQueue:
+		var cmd tea.Cmd
+		m.queueList, cmd = m.queueList.Update(msg)
+		cmds = append(cmds, cmd)
 	default:
 		// sidebar focus: nothing special yet
 	}
@@ -773,7 +1141,7 @@ func (m tuiModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	return m, tea.Batch(cmds...)
 }
 
-// cycleFocus cycles through the main focus areas: Playlists -> Search -> Playlists
+// cycleFocus cycles through the main focus areas: Playlists -> Search -> Queue -> Playlists
 func (m *tuiModel) cycleFocus() {
 	switch m.focus {
 	case focusPlaylists:
@@ -781,6 +1149,8 @@ func (m *tuiModel) cycleFocus() {
 	case focusTracks:
 		m.focus = focusSearch
 	case focusSearch, focusSearchResults:
+		m.focus = focusQueue
+	case focusQueue:
 		m.focus = focusPlaylists
 	default:
 		m.focus = focusPlaylists
@@ -789,19 +1159,20 @@ func (m *tuiModel) cycleFocus() {
 
 // playback helpers using existing playback.go functions
 
-func (m *tuiModel) playSelectedTrackFromList() {
+func (m *tuiModel) playSelectedTrackFromList() tea.Cmd {
 	if len(m.currentTracks) == 0 {
-		return
+		return nil
 	}
 	idx := m.trackList.Index()
 	if idx < 0 || idx >= len(m.currentTracks) {
-		return
+		return nil
 	}
-	track := m.currentTracks[idx].Track
+	track := m.currentTracks[idx]
 	if track.URI == "" {
 		m.status = "⚠️ Track URI not available"
-		return
+		return nil
 	}
+	var action tea.Cmd
 	// Prefer playlist context when possible, with correct offset
 	if m.currentPlaylistIdx >= 0 && m.currentPlaylistIdx < len(m.playlists) {
 		pl := m.playlists[m.currentPlaylistIdx]
@@ -811,41 +1182,77 @@ func (m *tuiModel) playSelectedTrackFromList() {
 		}
 		// Pass the track index as offset so playback starts from selected track
 		trackOffset := idx
-		go func() {
-			ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		action = playbackActionCmd(m.ctx, m.client, func(ctx context.Context, client *utils.SpotifyClient) error {
+			ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
 			defer cancel()
-			_ = ctx // currently unused, but ready for extensions
-			StartMusicWithOffset(&playlistURI, nil, &trackOffset)
-		}()
+			return StartMusicWithOffset(ctx, client, &playlistURI, nil, &trackOffset)
+		})
 	} else {
 		uris := []string{track.URI}
-		go StartMusic(nil, &uris)
+		action = playbackActionCmd(m.ctx, m.client, func(ctx context.Context, client *utils.SpotifyClient) error {
+			return StartMusic(ctx, client, nil, &uris)
+		})
 	}
 	m.isPlaying = true
 	m.currentTrackURI = track.URI
 	m.lastActionAt = time.Now()
+	m.errMsg = ""
 	m.status = fmt.Sprintf("🎵 Playing: %s — %s", track.Name, joinArtists(track.Artists))
+	return tea.Batch(action, actionRefreshCmd())
 }
 
-func (m *tuiModel) playSelectedSearchTrackFromList() {
+func (m *tuiModel) playSelectedSearchTrackFromList() tea.Cmd {
 	if len(m.searchTracks) == 0 {
-		return
+		return nil
 	}
 	idx := m.searchList.Index()
 	if idx < 0 || idx >= len(m.searchTracks) {
-		return
+		return nil
 	}
 	track := m.searchTracks[idx]
 	if track.URI == "" {
 		m.status = "⚠️ Track URI not available"
-		return
+		return nil
 	}
 	uris := []string{track.URI}
-	go StartMusic(nil, &uris)
 	m.isPlaying = true
 	m.currentTrackURI = track.URI
 	m.lastActionAt = time.Now()
+	m.errMsg = ""
 	m.status = fmt.Sprintf("🎵 Playing: %s — %s", track.Name, m.getSearchArtistNames(track.Artists))
+	return tea.Batch(playbackActionCmd(m.ctx, m.client, func(ctx context.Context, client *utils.SpotifyClient) error {
+		return StartMusic(ctx, client, nil, &uris)
+	}), actionRefreshCmd())
+}
+
+func (m *tuiModel) queueSelectedTrackFromList() tea.Cmd {
+	idx := m.trackList.Index()
+	if idx < 0 || idx >= len(m.currentTracks) {
+		return nil
+	}
+	track := m.currentTracks[idx]
+	return m.queueTrack(track.URI, track.Name, joinArtists(track.Artists))
+}
+
+func (m *tuiModel) queueSelectedSearchTrackFromList() tea.Cmd {
+	idx := m.searchList.Index()
+	if idx < 0 || idx >= len(m.searchTracks) {
+		return nil
+	}
+	track := m.searchTracks[idx]
+	return m.queueTrack(track.URI, track.Name, m.getSearchArtistNames(track.Artists))
+}
+
+// queueTrack adds a track to the queue without interrupting what is playing
+func (m *tuiModel) queueTrack(uri, name, artists string) tea.Cmd {
+	if uri == "" {
+		m.status = "⚠️ Track URI not available"
+		return nil
+	}
+	m.lastActionAt = time.Now()
+	m.errMsg = ""
+	m.status = fmt.Sprintf("➕ Queued: %s — %s", name, artists)
+	return addToQueueCmd(m.ctx, m.client, uri)
 }
 
 // artist helpers (reused logic from old TUI)
@@ -874,7 +1281,7 @@ func (m tuiModel) View() string {
 	if m.focus == focusSidebar {
 		sidebarStyle = sidebarStyle.BorderForeground(spotifyGreen)
 	}
-	if m.focus == focusPlaylists || m.focus == focusTracks || m.focus == focusSearch || m.focus == focusSearchResults {
+	if m.focus == focusPlaylists || m.focus == focusTracks || m.focus == focusSearch || m.focus == focusSearchResults || m.focus == focusQueue {
 		contentStyle = contentStyle.BorderForeground(spotifyGreen)
 	}
 
@@ -916,6 +1323,11 @@ func (m tuiModel) renderSidebar(width int) string {
 	}
 
 	rows = append(rows, "")
+	if m.userProfile != nil {
+		rows = append(rows, userStyle.Render("👤 "+m.userProfile.Username)+accountStyle.Render(" · "+m.account))
+	} else {
+		rows = append(rows, accountStyle.Render("👤 "+m.account))
+	}
 	rows = append(rows, dividerStyle.Render(strings.Repeat("─", width)))
 	rows = append(rows, "")
 
@@ -940,15 +1352,23 @@ func (m tuiModel) renderSidebar(width int) string {
 	rows = append(rows, "")
 
 	// Help section with better formatting
+	shortcut := func(keys, label string) string {
+		return helpStyle.Render(fmt.Sprintf("  %-6s%s", keys, label))
+	}
 	helpLines := []string{
 		"⌨️  Shortcuts",
 		"",
-		helpStyle.Render("  Tab  Cycle panels"),
-		helpStyle.Render("  /,s  Search"),
-		helpStyle.Render("  p    Playlists"),
-		helpStyle.Render("  ␣    Play/Pause"),
-		helpStyle.Render("  ←/→  Prev/Next"),
-		helpStyle.Render("  q    Quit"),
+		shortcut("Tab", "Cycle panels"),
+		shortcut(sidebarKey(m.keys.Search), "Search"),
+		shortcut(sidebarKey(m.keys.Playlists), "Playlists"),
+		shortcut(sidebarKey(m.keys.Pause), "Play/Pause"),
+		shortcut(sidebarKey(m.keys.Prev)+"/"+sidebarKey(m.keys.Next), "Prev/Next"),
+		shortcut(sidebarKey(m.keys.VolumeDown)+"/"+sidebarKey(m.keys.VolumeUp), "Volume"),
+		shortcut(sidebarKey(m.keys.SeekBack)+"/"+sidebarKey(m.keys.SeekForward), "Seek"),
+		shortcut(sidebarKey(m.keys.Shuffle)+"/"+sidebarKey(m.keys.Repeat), "Shuffle/Repeat"),
+		shortcut(sidebarKey(m.keys.AddToQueue), "Add to queue"),
+		shortcut(sidebarKey(m.keys.Queue), "Queue"),
+		shortcut(sidebarKey(m.keys.Quit), "Quit"),
 	}
 
 	for _, line := range helpLines {
@@ -966,6 +1386,8 @@ func (m tuiModel) renderContent(width int) string {
 		return m.renderPlaylistsAndTracks(width)
 	case focusSearch, focusSearchResults:
 		return m.renderSearch(width)
+	case focusQueue:
+		return m.renderQueue(width)
 	default:
 		return m.renderPlaylistsAndTracks(width)
 	}
@@ -1101,6 +1523,33 @@ func (m tuiModel) renderSearch(width int) string {
 	return lipgloss.JoinVertical(lipgloss.Left, sections...)
 }
 
+func (m tuiModel) renderQueue(width int) string {
+	var sections []string
+
+	sections = append(sections, sectionHeader.Render("📜 Queue"))
+	sections = append(sections, "")
+
+	switch {
+	case m.queue == nil:
+		sections = append(sections, helpStyle.Render("⏳ Loading queue…"))
+	case m.queue.CurrentlyPlaying == nil:
+		sections = append(sections, helpStyle.Render("Nothing is playing"))
+	default:
+		now := m.queue.CurrentlyPlaying
+		sections = append(sections, nowPlayingStyle.Render("🎵 "+now.Name+" — "+joinArtists(now.Artists)))
+		sections = append(sections, "")
+		if len(m.queue.Queue) == 0 {
+			sections = append(sections, helpStyle.Render("Nothing up next · press "+m.keys.AddToQueue.Help().Key+" on a track to queue it"))
+		} else {
+			m.queueList.SetWidth(width - 2)
+			m.queueList.SetHeight(m.height - 14)
+			sections = append(sections, m.queueList.View())
+		}
+	}
+
+	return lipgloss.JoinVertical(lipgloss.Left, sections...)
+}
+
 func (m tuiModel) renderStatusBar() string {
 	// Left side: playback status
 	var leftParts []string
@@ -1113,8 +1562,10 @@ func (m tuiModel) renderStatusBar() string {
 		leftParts = append(leftParts, pauseIcon)
 	}
 
-	// Status message
-	if m.status != "" {
+	// Status message; a failed action stays visible until the next one
+	if m.errMsg != "" {
+		leftParts = append(leftParts, errorStyle.Render("⚠ "+m.errMsg))
+	} else if m.status != "" {
 		statusMsg := m.status
 		if strings.HasPrefix(statusMsg, "Error") {
 			statusMsg = errorStyle.Render("⚠ " + statusMsg)
@@ -1128,8 +1579,8 @@ func (m tuiModel) renderStatusBar() string {
 
 	leftContent := strings.Join(leftParts, " │ ")
 
-	// Right side: controls hint
-	rightContent := helpStyle.Render("␣: Play/Pause  ←→: Skip  q: Quit")
+	// Right side: player settings, then controls hint
+	rightContent := helpStyle.Render(m.playerSettings() + "  ␣: Play/Pause  ←→: Skip  q: Quit")
 
 	// Calculate spacing
 	leftLen := lipgloss.Width(leftContent)
@@ -1169,24 +1620,57 @@ func (m tuiModel) renderStatusBar() string {
 		Render(fullStatus)
 }
 
+// playerSettings summarizes volume, shuffle and repeat for the status bar
+func (m tuiModel) playerSettings() string {
+	var parts []string
+	if m.volume >= 0 {
+		parts = append(parts, fmt.Sprintf("🔊 %d%%", m.volume))
+	}
+	if m.shuffle {
+		parts = append(parts, "🔀 on")
+	} else {
+		parts = append(parts, "🔀 off")
+	}
+	if m.repeat != "" {
+		parts = append(parts, "🔁 "+m.repeat)
+	}
+	return strings.Join(parts, "  ")
+}
+
+// nextRepeatMode cycles off → context → track → off
+func nextRepeatMode(mode string) string {
+	for i, m := range repeatModes {
+		if m == mode {
+			return repeatModes[(i+1)%len(repeatModes)]
+		}
+	}
+	return repeatModes[1]
+}
+
 // TUI command
 var tuiCmd = &cobra.Command{
-	Use:   "tui",
-	Short: "Launch the Terminal User Interface",
-	Long:  "Launch GitifyTUI - A Bubble Tea powered terminal interface for Spotify, inspired by Lazygit and Charmbracelet UIs.",
-	Run: func(cmd *cobra.Command, args []string) {
-		// Avoid stdout prints from playback helpers so Bubble Tea layout
-		// stays intact (no random lines injected into the UI).
-		SetPlaybackSilent(true)
-
-		p := tea.NewProgram(initialModel(), tea.WithAltScreen())
+	Use:         "tui",
+	Short:       "Launch the Terminal User Interface",
+	Long:        "Launch GitifyTUI - A Bubble Tea powered terminal interface for Spotify, inspired by Lazygit and Charmbracelet UIs.",
+	Annotations: needsScopes("playlist-read-private", "user-read-playback-state", "user-modify-playback-state"),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if err := applyTheme(utils.Theme); err != nil {
+			fmt.Fprintln(os.Stderr, "Warning:", err)
+		}
+
+		// A missing login is fine here; the TUI tells the user how to fix it
+		client, _ := spotifyClient()
+
+		p := tea.NewProgram(initialModel(cmd.Context(), client), tea.WithAltScreen(), tea.WithContext(cmd.Context()))
 		if _, err := p.Run(); err != nil {
-			fmt.Fprintf(os.Stderr, "Error running TUI: %v\n", err)
-			os.Exit(1)
+			return fmt.Errorf("error running TUI: %v", err)
 		}
+		return nil
 	},
 }
 
 func init() {
+	buildStyles()
+	utils.ThemeNames = themeNames()
 	spotifyCmd.AddCommand(tuiCmd)
-}
\ No newline at end of file
+}
@@ -0,0 +1,197 @@
+// Named accounts: each keeps its own token and profile. The default account lives
+// directly in the config directory, where single-account versions kept its files;
+// the others live in accounts/<name>.
+
+package utils
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// DefaultAccount is used until another account is selected
+const DefaultAccount = "default"
+
+// AccountOverride is set from the --account flag and wins over GITIFY_ACCOUNT and
+// the account chosen with `account use`
+var AccountOverride string
+
+var accountNameRe = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9._-]{0,63}$`)
+
+// activeAccountFile remembers the account chosen with `account use`
+const activeAccountFile = "active-account"
+
+// Files that make up an account's saved state, cache included
+var accountFiles = []string{"token.json", "token.enc", "profile.json", "cache"}
+
+// ValidateAccountName rejects names that can't be used as a directory name
+func ValidateAccountName(name string) error {
+	if !accountNameRe.MatchString(name) {
+		return fmt.Errorf("invalid account name %q: use letters, digits, '.', '_' or '-'", name)
+	}
+	return nil
+}
+
+// ActiveAccount returns the account commands act on: --account, then GITIFY_ACCOUNT,
+// then the one chosen with `account use`, then DefaultAccount.
+func ActiveAccount() string {
+	if AccountOverride != "" {
+		return AccountOverride
+	}
+	if name := os.Getenv("GITIFY_ACCOUNT"); name != "" {
+		return name
+	}
+	if name := savedActiveAccount(); name != "" {
+		return name
+	}
+	return DefaultAccount
+}
+
+func savedActiveAccount() string {
+	dir, err := ConfigDir()
+	if err != nil {
+		return ""
+	}
+	data, err := os.ReadFile(filepath.Join(dir, activeAccountFile))
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(string(data))
+}
+
+// SetActiveAccount makes name the account used when neither --account nor
+// GITIFY_ACCOUNT is given
+func SetActiveAccount(name string) error {
+	if err := ValidateAccountName(name); err != nil {
+		return err
+	}
+	dir, err := ConfigDir()
+	if err != nil {
+		return err
+	}
+	return WriteFileAtomic(filepath.Join(dir, activeAccountFile), []byte(name+"\n"), 0600)
+}
+
+// AccountDir returns the directory holding an account's files. It is created by the
+// first write into it.
+func AccountDir(name string) (string, error) {
+	if err := ValidateAccountName(name); err != nil {
+		return "", err
+	}
+	dir, err := ConfigDir()
+	if err != nil {
+		return "", err
+	}
+	if name == DefaultAccount {
+		return dir, nil
+	}
+
+	return filepath.Join(dir, "accounts", name), nil
+}
+
+// AccountFile returns the path of one of an account's files, e.g. "token.json"
+func AccountFile(account, name string) (string, error) {
+	dir, err := AccountDir(account)
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, name), nil
+}
+
+// AccountExists reports whether an account has saved credentials
+func AccountExists(name string) bool {
+	for _, file := range []string{"token.json", "token.enc"} {
+		path, err := AccountFile(name, file)
+		if err != nil {
+			return false
+		}
+		if _, err := os.Stat(path); err == nil {
+			return true
+		}
+	}
+	return false
+}
+
+// Accounts lists the accounts with saved credentials, sorted by name
+func Accounts() ([]string, error) {
+	dir, err := ConfigDir()
+	if err != nil {
+		return nil, err
+	}
+
+	var names []string
+	if AccountExists(DefaultAccount) {
+		names = append(names, DefaultAccount)
+	}
+
+	entries, err := os.ReadDir(filepath.Join(dir, "accounts"))
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return nil, err
+	}
+	for _, e := range entries {
+		if e.IsDir() && ValidateAccountName(e.Name()) == nil && AccountExists(e.Name()) {
+			names = append(names, e.Name())
+		}
+	}
+
+	sort.Strings(names)
+	return names, nil
+}
+
+// Logout deletes an account's token and profile and returns the files it removed.
+// The account stays selected, so the next login goes to it.
+func Logout(name string) ([]string, error) {
+	dir, err := AccountDir(name)
+	if err != nil {
+		return nil, err
+	}
+
+	var removed []string
+	for _, file := range accountFiles {
+		path := filepath.Join(dir, file)
+		if _, err := os.Lstat(path); errors.Is(err, fs.ErrNotExist) {
+			continue
+		}
+		if err := os.RemoveAll(path); err != nil {
+			return removed, err
+		}
+		removed = append(removed, path)
+	}
+	return removed, nil
+}
+
+// RemoveAccount logs an account out and deletes its directory. If it was the account
+// chosen with `account use`, the choice falls back to DefaultAccount.
+func RemoveAccount(name string) error {
+	if _, err := Logout(name); err != nil {
+		return err
+	}
+
+	// The default account's directory is the config directory itself
+	if name != DefaultAccount {
+		dir, err := AccountDir(name)
+		if err != nil {
+			return err
+		}
+		if err := os.RemoveAll(dir); err != nil {
+			return err
+		}
+	}
+
+	if savedActiveAccount() == name {
+		configDir, err := ConfigDir()
+		if err != nil {
+			return err
+		}
+		if err := os.Remove(filepath.Join(configDir, activeAccountFile)); err != nil && !errors.Is(err, fs.ErrNotExist) {
+			return err
+		}
+	}
+	return nil
+}
@@ -1,10 +1,9 @@
-// TODO - Create a UNIVERSAL HTTP CLIENT INSTEAD OF OPENING AND CLOSING AGAIN WHILE CALLING APIS
-
 // use mux router only when multiple routes are used
 // This is all logics of OAUTH 2.0 used in Spotify
 package utils
 
 import (
+	"context"
 	"crypto/rand"
 	"encoding/hex"
 	"encoding/json"
@@ -12,38 +11,109 @@ import (
 	"io"
 	"net/http"
 	"net/url"
-	"os"
 	"strings"
+	"sync"
+	"time"
 
 	"github.com/joho/godotenv"
 )
 
-
 var RedirectUrl string
 var Client_ID string
 var Client_Secret string
 
+// Base URLs for the Spotify Web API and the accounts (OAuth) service. They can be
+// pointed at a local stand-in server with SPOTIFY_API_BASE_URL / SPOTIFY_ACCOUNTS_BASE_URL.
+var APIBaseURL string
+var AccountsBaseURL string
+
+// AuthFlow is AuthFlowCode or AuthFlowPKCE, from SPOTIFY_AUTH_FLOW. It defaults to
+// PKCE when no CLIENT_SECRET is configured.
+var AuthFlow string
+
 func init() { // this will be called because cmd folder is imported in main.go( you need to think in terms of main.go because thats where everythig is done)
 	// Load .env file directly here to ensure it's loaded before we read env vars
 	godotenv.Load()
-	
-	Client_ID = os.Getenv("CLIENT_ID")
-	Client_Secret = os.Getenv("CLIENT_SECRET")
-	RedirectUrl = os.Getenv("REDIRECT_URL")
-	
-	if RedirectUrl == "" {
-		RedirectUrl = "http://localhost:8080/callback"
+
+	// Environment and defaults only; the config file is read once flags are parsed
+	applyConfig()
+}
+
+// newTokenRequest builds a POST to the token endpoint. The client authenticates with
+// basic auth for the code flow, or only sends its client_id for PKCE (no secret involved).
+func newTokenRequest(ctx context.Context, tokenURL string, data url.Values) (*http.Request, error) {
+	if usePKCE() {
+		data.Set("client_id", Client_ID)
 	}
+
+	req, err := http.NewRequestWithContext(ctx, "POST", tokenURL, strings.NewReader(data.Encode()))
+	if err != nil {
+		return nil, err
+	}
+
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	if !usePKCE() {
+		req.SetBasicAuth(Client_ID, Client_Secret)
+	}
+	return req, nil
 }
 
 type SpotfiyToken struct {
-	AccessToken string `json:"access_token"`
-	TokenType string    `json:"token_type"`
-	RefreshToken string	 `json:"refresh_token"`
-	Scope string		`json:"scope"`
-	ExpiresIn int		`json:"expires_in"`
+	AccessToken  string `json:"access_token"`
+	TokenType    string `json:"token_type"`
+	RefreshToken string `json:"refresh_token"`
+	Scope        string `json:"scope"`
+	ExpiresIn    int    `json:"expires_in"`
+	// ExpiresAt is the absolute expiry, computed from ExpiresIn when the token is issued
+	ExpiresAt time.Time `json:"expires_at,omitzero"`
 }
 
+// tokenExpirySkew is how long before ExpiresAt a token is already treated as expired,
+// so requests don't race the deadline.
+const tokenExpirySkew = 60 * time.Second
+
+// accountsClient talks to the accounts service. The timeout matters most for refreshes,
+// which run while refreshMu holds up every other request.
+var accountsClient = &http.Client{Timeout: 30 * time.Second}
+
+// refreshMu makes token refreshes single-flight across goroutines.
+var refreshMu sync.Mutex
+
+func (t *SpotfiyToken) setExpiry() {
+	t.ExpiresAt = time.Now().Add(time.Duration(t.ExpiresIn) * time.Second)
+}
+
+// expiresSoon reports whether the token should be refreshed before use.
+// Tokens saved before ExpiresAt existed have no expiry and rely on the 401 path.
+func (t *SpotfiyToken) expiresSoon() bool {
+	if t.ExpiresAt.IsZero() {
+		return false
+	}
+	return time.Until(t.ExpiresAt) < tokenExpirySkew
+}
+
+// StoredToken returns the active account's saved token as is, without refreshing it
+func StoredToken() (*SpotfiyToken, error) {
+	return loadToken()
+}
+
+// loadToken reads the saved token from the configured credential store
+func loadToken() (*SpotfiyToken, error) {
+	store, err := credentialStore()
+	if err != nil {
+		return nil, err
+	}
+	return store.Load()
+}
+
+// saveToken hands the token to the configured credential store
+func saveToken(token *SpotfiyToken) error {
+	store, err := credentialStore()
+	if err != nil {
+		return err
+	}
+	return store.Save(token)
+}
 
 func generateRandomString(length int) (string, error) {
 	bytes := make([]byte, length/2) // 2 hex chars per byte
@@ -53,26 +123,25 @@ func generateRandomString(length int) (string, error) {
 	return hex.EncodeToString(bytes), nil
 }
 
+// exchangeToken trades an authorization code for tokens. verifier is the PKCE
+// code_verifier and is ignored for the confidential code flow.
+func exchangeToken(ctx context.Context, code, verifier string) (*SpotfiyToken, error) {
+	tokenURL := AccountsBaseURL + "/api/token" // here the request goes in encoded form and it is POST so it is not query params
 
-func exchangeToken(code string) (*SpotfiyToken, error) {
-	tokenURL := "https://accounts.spotify.com/api/token" // here the request goes in encoded form and it is POST so it is not query params
-
-	data:= url.Values{}  // this is used for form encoded data or query parameters (here it is form encoded)
+	data := url.Values{} // this is used for form encoded data or query parameters (here it is form encoded)
 	data.Set("grant_type", "authorization_code")
 	data.Set("code", code)
-	data.Set("redirect_uri",RedirectUrl)
+	data.Set("redirect_uri", RedirectUrl)
+	if usePKCE() {
+		data.Set("code_verifier", verifier)
+	}
 
-	req, err := http.NewRequest("POST", tokenURL, strings.NewReader(data.Encode()))
+	req, err := newTokenRequest(ctx, tokenURL, data)
 	if err != nil {
 		return nil, err
 	}
 
-	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
-	req.SetBasicAuth(Client_ID,Client_Secret)
-
-	client := &http.Client{}
-
-	resp, err := client.Do(req)
+	resp, err := accountsClient.Do(req)
 	if err != nil {
 		return nil, err
 	}
@@ -92,108 +161,73 @@ func exchangeToken(code string) (*SpotfiyToken, error) {
 	if err := json.Unmarshal(body, &token); err != nil {
 		return nil, err
 	}
+	token.setExpiry()
 
 	return &token, nil
 }
 
-
 // callback function is used by spotify.com/authorize when that endpoint is hit by login
-func HandleCallback(w http.ResponseWriter, r *http.Request){
-	code := r.URL.Query().Get("code")
-	if code==""{
-		http.Error(w,"No code in request",http.StatusBadRequest)
-		return 
-	}
-
-	token, err := exchangeToken(code)
-	if err != nil {
-		http.Error(w, fmt.Sprintf("failed to exchange token: %v", err), http.StatusInternalServerError)
-		return
-	}
+func HandleCallback(w http.ResponseWriter, r *http.Request) {
+	NewCallbackHandler(nil)(w, r)
+}
 
-	file, err := os.Create("token.json")
+func LoginHandler(w http.ResponseWriter, r *http.Request) {
+	authURL, err := AuthorizeURL()
 	if err != nil {
-		http.Error(w, fmt.Sprintf("failed to create token file: %v", err), http.StatusInternalServerError)
-		return
-	}
-	defer file.Close()
-
-	encoder := json.NewEncoder(file)
-	encoder.SetIndent("", "  ")
-	if err := encoder.Encode(token); err != nil {
-		http.Error(w, fmt.Sprintf("failed to write token to file: %v", err), http.StatusInternalServerError)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
 	}
 
-	w.WriteHeader(http.StatusOK)
-	w.Write([]byte(" Login successful! You can close this tab now."))
-
-	fmt.Println("Access token saved successfully to token.json")
+	http.Redirect(w, r, authURL, http.StatusFound)
 }
 
-
-func LoginHandler(w http.ResponseWriter, r *http.Request){
-	state, err:=generateRandomString(16)
-	if err!=nil{
-		http.Error(w,"failed to generate random string",http.StatusBadRequest)
-		return
-	}
-	
-	scope := "user-read-private user-read-email user-library-read playlist-read-private user-read-playback-state user-modify-playback-state streaming"
-	authURL, _ := url.Parse("https://accounts.spotify.com/authorize")
-	params := url.Values{}
-	params.Add("client_id", Client_ID)
-	params.Add("response_type", "code")
-	params.Add("redirect_uri", RedirectUrl)
-	params.Add("scope", scope)
-	params.Add("state", state)
-
-	authURL.RawQuery = params.Encode()
-
-	http.Redirect(w, r, authURL.String(), http.StatusFound)
+// RefreshToken exchanges the stored refresh token for a new access token and saves it.
+// Only one refresh runs at a time; concurrent callers wait for it to finish.
+func RefreshToken(ctx context.Context) error {
+	refreshMu.Lock()
+	defer refreshMu.Unlock()
+	return refreshTokenLocked(ctx, AccountsBaseURL)
 }
 
-func RefreshToken() error {
-	var existing_token SpotfiyToken
-
-	
-	file, err := os.ReadFile("token.json")
+// refreshTokenLocked does the actual refresh against the given accounts service base URL.
+// Callers must hold refreshMu.
+func refreshTokenLocked(ctx context.Context, accountsBaseURL string) error {
+	existing_token, err := loadToken()
 	if err != nil {
 		return err
 	}
 
-	err = json.Unmarshal(file, &existing_token)
-	if err != nil {
-		return err
-	}
-
-	tokenURL := "https://accounts.spotify.com/api/token"
+	tokenURL := accountsBaseURL + "/api/token"
 
 	data := url.Values{}
 	data.Set("grant_type", "refresh_token")
 	data.Set("refresh_token", existing_token.RefreshToken)
 
-	req, err := http.NewRequest("POST", tokenURL, strings.NewReader(data.Encode()))
+	req, err := newTokenRequest(ctx, tokenURL, data)
 	if err != nil {
 		return err
 	}
 
-	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
-	req.SetBasicAuth(Client_ID,Client_Secret)
-
-	client := &http.Client{}
-	resp, err := client.Do(req)
+	resp, err := accountsClient.Do(req)
 	if err != nil {
 		return err
 	}
 	defer resp.Body.Close()
 
-	
 	body, err := io.ReadAll(resp.Body) // since you are using io.Readall() readall takes in all the incoming chunks of the data all togehter first and then converts to bytes so you cant use json.Encoder and decoder if you dont do this you can do the json.Encoder and Decoder
 	if err != nil {
 		return err
 	}
 
+	if resp.StatusCode != http.StatusOK {
+		err := fmt.Errorf("token endpoint returned status %d: %s", resp.StatusCode, string(body))
+		// A rejected refresh token means the user has to login again
+		if resp.StatusCode == http.StatusBadRequest || resp.StatusCode == http.StatusUnauthorized {
+			err = fmt.Errorf("%w: %v", ErrUnauthorized, err)
+		}
+		return err
+	}
+
 	var new_token SpotfiyToken
 	err = json.Unmarshal(body, &new_token)
 	if err != nil {
@@ -203,23 +237,15 @@ func RefreshToken() error {
 	existing_token.AccessToken = new_token.AccessToken
 	existing_token.TokenType = new_token.TokenType
 	existing_token.ExpiresIn = new_token.ExpiresIn
+	existing_token.setExpiry()
 
-	// Refresh token may or may not be present
+	// Refresh token and scope may or may not be present
 	if new_token.RefreshToken != "" {
 		existing_token.RefreshToken = new_token.RefreshToken
 	}
-
-	updatedData, err := json.MarshalIndent(existing_token, "", "  ")
-	if err != nil {
-		return err
+	if new_token.Scope != "" {
+		existing_token.Scope = new_token.Scope
 	}
 
-	err = os.WriteFile("token.json", updatedData, 0644)
-	if err != nil {
-		return err
-	}
-
-	fmt.Println("Access token refreshed successfully!")
-	return nil
+	return saveToken(existing_token)
 }
-
@@ -0,0 +1,17 @@
+package utils
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func TestTokenWithoutExpiryOmitsExpiresAt(t *testing.T) {
+	data, err := json.Marshal(SpotfiyToken{AccessToken: "access", RefreshToken: "refresh"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(string(data), "expires_at") {
+		t.Errorf("token without an expiry marshals to %s", data)
+	}
+}
@@ -0,0 +1,92 @@
+// A small on-disk cache of API results, so shell completion and status bars don't call
+// Spotify on every keystroke. Entries are JSON files in the active account's cache
+// directory; their age is the file's modification time.
+
+package utils
+
+import (
+	"encoding/json"
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// staleLockAge is how old a refresh lock must be before it is taken over: older than
+// any request can take, so its holder has died
+const staleLockAge = time.Minute
+
+func cachePath(name string) (string, error) {
+	return AccountFile(ActiveAccount(), filepath.Join("cache", name+".json"))
+}
+
+// ReadCache decodes the entry name into v and reports whether it was found and is
+// younger than ttl
+func ReadCache(name string, ttl time.Duration, v any) bool {
+	age, ok := ReadCacheAge(name, v)
+	return ok && age <= ttl
+}
+
+// ReadCacheAge decodes the entry name into v however old it is, and returns its age
+// and whether it was found
+func ReadCacheAge(name string, v any) (time.Duration, bool) {
+	path, err := cachePath(name)
+	if err != nil {
+		return 0, false
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		return 0, false
+	}
+	data, err := os.ReadFile(path)
+	if err != nil || json.Unmarshal(data, v) != nil {
+		return 0, false
+	}
+	return time.Since(info.ModTime()), true
+}
+
+// LockCache takes the lock on refreshing the entry name, so processes sharing an
+// entry don't all refresh it at once. It reports false when another process holds
+// the lock; otherwise the returned func releases it.
+func LockCache(name string) (unlock func(), ok bool) {
+	path, err := cachePath(name)
+	if err != nil {
+		return nil, false
+	}
+	path += ".lock"
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return nil, false
+	}
+
+	for attempt := 0; attempt < 2; attempt++ {
+		f, err := os.OpenFile(path, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0600)
+		if err == nil {
+			f.Close()
+			return func() { os.Remove(path) }, true
+		}
+		if !errors.Is(err, fs.ErrExist) {
+			return nil, false
+		}
+		// Take over a lock its holder left behind, then try once more
+		info, err := os.Stat(path)
+		if err != nil || time.Since(info.ModTime()) < staleLockAge {
+			return nil, false
+		}
+		os.Remove(path)
+	}
+	return nil, false
+}
+
+// WriteCache stores v as the entry name
+func WriteCache(name string, v any) error {
+	path, err := cachePath(name)
+	if err != nil {
+		return err
+	}
+	data, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	return WriteFileAtomic(path, data, 0600)
+}
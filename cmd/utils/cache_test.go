@@ -0,0 +1,71 @@
+package utils
+
+import (
+	"os"
+	"testing"
+	"time"
+)
+
+func TestLockCache(t *testing.T) {
+	t.Setenv("GITIFY_CONFIG_DIR", t.TempDir())
+	t.Setenv("GITIFY_ACCOUNT", "")
+
+	unlock, ok := LockCache("status")
+	if !ok {
+		t.Fatal("could not take a free lock")
+	}
+	if _, ok := LockCache("status"); ok {
+		t.Fatal("took a lock that is already held")
+	}
+	if _, ok := LockCache("devices"); !ok {
+		t.Error("a lock on one entry blocked another entry")
+	}
+	unlock()
+	unlock, ok = LockCache("status")
+	if !ok {
+		t.Fatal("could not take a released lock")
+	}
+
+	// A holder that died without unlocking doesn't block refreshes forever
+	path, err := cachePath("status")
+	if err != nil {
+		t.Fatal(err)
+	}
+	old := time.Now().Add(-2 * staleLockAge)
+	if err := os.Chtimes(path+".lock", old, old); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := LockCache("status"); !ok {
+		t.Error("could not take over an abandoned lock")
+	}
+	unlock()
+}
+
+func TestReadCacheAge(t *testing.T) {
+	t.Setenv("GITIFY_CONFIG_DIR", t.TempDir())
+	t.Setenv("GITIFY_ACCOUNT", "")
+
+	var v string
+	if _, ok := ReadCacheAge("status", &v); ok {
+		t.Fatal("found an entry that was never written")
+	}
+	if err := WriteCache("status", "cached"); err != nil {
+		t.Fatal(err)
+	}
+	path, err := cachePath("status")
+	if err != nil {
+		t.Fatal(err)
+	}
+	old := time.Now().Add(-time.Hour)
+	if err := os.Chtimes(path, old, old); err != nil {
+		t.Fatal(err)
+	}
+
+	if ReadCache("status", time.Minute, &v) {
+		t.Error("ReadCache returned an entry older than its TTL")
+	}
+	age, ok := ReadCacheAge("status", &v)
+	if !ok || v != "cached" || age < time.Hour {
+		t.Errorf("ReadCacheAge = %v, %v with %q, want an hour old entry", age, ok, v)
+	}
+}
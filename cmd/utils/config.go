@@ -0,0 +1,377 @@
+// Settings come from, in order of precedence: command line flags, environment variables
+// (including .env), the config file, then built-in defaults. Flags are applied by the
+// commands that have them; everything else is resolved here.
+
+package utils
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Settings resolved by LoadConfig, next to Client_ID and friends in api.go
+var (
+	// DefaultDevice is the device name or ID playback commands target when none is active
+	DefaultDevice string
+	// SearchLimit is how many results a search returns
+	SearchLimit int
+	// PollInterval is how often playback state is refreshed
+	PollInterval time.Duration
+	// Theme names the TUI color theme
+	Theme string
+	// ThemeNames lists the themes the theme setting accepts; the TUI, which defines
+	// them, fills it in
+	ThemeNames []string
+	// Keybindings maps TUI actions to comma separated keys, overriding the defaults
+	Keybindings map[string]string
+)
+
+// minPollInterval keeps poll_interval from hammering the API
+const minPollInterval = 250 * time.Millisecond
+
+// keybindingPrefix namespaces the per-action keybinding settings, e.g. "keybindings.quit"
+const keybindingPrefix = "keybindings."
+
+// Setting describes one configuration key
+type Setting struct {
+	Key     string
+	Env     string // environment variable that overrides the file, if any
+	Default string
+	Usage   string
+	Secret  bool // masked by `config list`
+
+	// parse validates a value and converts it to what is written to the file
+	parse func(string) (any, error)
+}
+
+// Settings lists every configuration key except the keybindings.<action> family
+var Settings = []Setting{
+	{Key: "client_id", Env: "CLIENT_ID", Usage: "Spotify app client ID", parse: parseString},
+	{Key: "client_secret", Env: "CLIENT_SECRET", Usage: "Spotify app client secret (optional with PKCE)", Secret: true, parse: parseString},
+	{Key: "redirect_url", Env: "REDIRECT_URL", Default: "http://localhost:8080/callback", Usage: "OAuth redirect URL registered for the app", parse: parseString},
+	{Key: "auth_flow", Env: "SPOTIFY_AUTH_FLOW", Usage: "code or pkce (default pkce without a client secret)", parse: parseOneOf(AuthFlowCode, AuthFlowPKCE)},
+	{Key: "api_base_url", Env: "SPOTIFY_API_BASE_URL", Default: "https://api.spotify.com/v1", Usage: "Spotify Web API base URL", parse: parseString},
+	{Key: "accounts_base_url", Env: "SPOTIFY_ACCOUNTS_BASE_URL", Default: "https://accounts.spotify.com", Usage: "Spotify accounts service base URL", parse: parseString},
+	{Key: "default_device", Env: "GITIFY_DEVICE", Usage: "device name or ID to play on when no device is active", parse: parseString},
+	{Key: "search_limit", Env: "GITIFY_SEARCH_LIMIT", Default: "10", Usage: "number of search results (1-50)", parse: parseIntRange(1, 50)},
+	{Key: "poll_interval", Env: "GITIFY_POLL_INTERVAL", Default: "500ms", Usage: "how often the TUI refreshes playback state", parse: parseMinDuration(minPollInterval)},
+	{Key: "theme", Env: "GITIFY_THEME", Default: "spotify", Usage: "TUI color theme", parse: parseTheme},
+}
+
+// ConfigFileOverride is set from the --config flag
+var ConfigFileOverride string
+
+var (
+	configMu   sync.Mutex
+	fileValues = map[string]any{} // contents of the config file
+	fileErr    error              // why the file could not be read, if it couldn't
+)
+
+// ConfigFilePath returns the config file in use: --config, GITIFY_CONFIG, or
+// config.yaml in the config directory.
+func ConfigFilePath() (string, error) {
+	if ConfigFileOverride != "" {
+		return ConfigFileOverride, nil
+	}
+	if path := os.Getenv("GITIFY_CONFIG"); path != "" {
+		return path, nil
+	}
+	dir, err := ConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "config.yaml"), nil
+}
+
+// LoadConfig reads the config file, if there is one, and resolves every setting. On
+// error the settings still resolve, ignoring the file or the invalid values.
+func LoadConfig() error {
+	path, err := ConfigFilePath()
+	if err != nil {
+		return err
+	}
+
+	values := map[string]any{}
+	data, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		err = nil
+	} else if err != nil {
+		err = fmt.Errorf("could not read config file: %v", err)
+	} else if err = yaml.Unmarshal(data, &values); err != nil {
+		err = fmt.Errorf("could not parse config file %s: %v", path, err)
+	}
+	if values == nil || err != nil {
+		values = map[string]any{}
+	}
+
+	configMu.Lock()
+	fileValues, fileErr = values, err
+	configMu.Unlock()
+
+	if applyErr := applyConfig(); err == nil {
+		err = applyErr
+	}
+	return err
+}
+
+// applyConfig copies the resolved settings into the package variables
+func applyConfig() error {
+	Client_ID, _ = ConfigValue("client_id")
+	Client_Secret, _ = ConfigValue("client_secret")
+	RedirectUrl, _ = ConfigValue("redirect_url")
+
+	api, _ := ConfigValue("api_base_url")
+	APIBaseURL = strings.TrimRight(api, "/")
+	accounts, _ := ConfigValue("accounts_base_url")
+	AccountsBaseURL = strings.TrimRight(accounts, "/")
+
+	flow, _ := ConfigValue("auth_flow")
+	AuthFlow = strings.ToLower(flow)
+	if AuthFlow != AuthFlowCode && AuthFlow != AuthFlowPKCE {
+		AuthFlow = AuthFlowCode
+		if Client_Secret == "" {
+			AuthFlow = AuthFlowPKCE
+		}
+	}
+
+	DefaultDevice, _ = ConfigValue("default_device")
+	Theme, _ = ConfigValue("theme")
+
+	var errs []error
+	limit, _ := ConfigValue("search_limit")
+	if v, err := parseIntRange(1, 50)(limit); err != nil {
+		errs = append(errs, fmt.Errorf("search_limit: %v", err))
+		SearchLimit = 10
+	} else {
+		SearchLimit = v.(int)
+	}
+
+	interval, _ := ConfigValue("poll_interval")
+	if v, err := parseMinDuration(minPollInterval)(interval); err != nil {
+		errs = append(errs, fmt.Errorf("poll_interval: %v", err))
+		PollInterval = 500 * time.Millisecond
+	} else {
+		PollInterval, _ = time.ParseDuration(v.(string))
+	}
+
+	Keybindings = map[string]string{}
+	for _, key := range ConfigKeys() {
+		if action, ok := strings.CutPrefix(key, keybindingPrefix); ok {
+			Keybindings[action], _ = ConfigValue(key)
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+// FindSetting looks up a key. keybindings.<action> keys are accepted for any action;
+// the TUI decides which actions exist.
+func FindSetting(key string) (Setting, bool) {
+	for _, s := range Settings {
+		if s.Key == key {
+			return s, true
+		}
+	}
+	if action, ok := strings.CutPrefix(key, keybindingPrefix); ok && action != "" {
+		return Setting{Key: key, Usage: "keys for the " + action + " action, comma separated", parse: parseString}, true
+	}
+	return Setting{}, false
+}
+
+// ConfigValue returns the effective value of key and where it came from: "env NAME",
+// "file" or "default".
+func ConfigValue(key string) (value, source string) {
+	s, ok := FindSetting(key)
+	if !ok {
+		return "", ""
+	}
+	if s.Env != "" {
+		if v, ok := os.LookupEnv(s.Env); ok && v != "" {
+			return v, "env " + s.Env
+		}
+	}
+	if v, ok := fileValue(key); ok {
+		return v, "file"
+	}
+	return s.Default, "default"
+}
+
+// ConfigKeys returns every known key plus the keybindings set in the file, sorted
+func ConfigKeys() []string {
+	var keys []string
+	for _, s := range Settings {
+		keys = append(keys, s.Key)
+	}
+
+	configMu.Lock()
+	if bindings, ok := fileValues["keybindings"].(map[string]any); ok {
+		for action := range bindings {
+			keys = append(keys, keybindingPrefix+action)
+		}
+	}
+	configMu.Unlock()
+
+	sort.Strings(keys)
+	return keys
+}
+
+func fileValue(key string) (string, bool) {
+	configMu.Lock()
+	defer configMu.Unlock()
+
+	var v any
+	var ok bool
+	if action, isBinding := strings.CutPrefix(key, keybindingPrefix); isBinding {
+		bindings, _ := fileValues["keybindings"].(map[string]any)
+		v, ok = bindings[action]
+	} else {
+		v, ok = fileValues[key]
+	}
+	if !ok || v == nil {
+		return "", false
+	}
+	return fmt.Sprint(v), true
+}
+
+// SetConfigValue validates value for key and writes it to the config file
+func SetConfigValue(key, value string) error {
+	s, ok := FindSetting(key)
+	if !ok {
+		return fmt.Errorf("unknown config key %q", key)
+	}
+	parsed, err := s.parse(value)
+	if err != nil {
+		return fmt.Errorf("invalid value for %s: %v", key, err)
+	}
+
+	return updateConfigFile(func(values map[string]any) {
+		if action, ok := strings.CutPrefix(key, keybindingPrefix); ok {
+			bindings, _ := values["keybindings"].(map[string]any)
+			if bindings == nil {
+				bindings = map[string]any{}
+			}
+			bindings[action] = parsed
+			values["keybindings"] = bindings
+			return
+		}
+		values[key] = parsed
+	})
+}
+
+// UnsetConfigValue removes key from the config file so the default applies again
+func UnsetConfigValue(key string) error {
+	if _, ok := FindSetting(key); !ok {
+		return fmt.Errorf("unknown config key %q", key)
+	}
+
+	return updateConfigFile(func(values map[string]any) {
+		if action, ok := strings.CutPrefix(key, keybindingPrefix); ok {
+			if bindings, _ := values["keybindings"].(map[string]any); bindings != nil {
+				delete(bindings, action)
+				if len(bindings) == 0 {
+					delete(values, "keybindings")
+				}
+			}
+			return
+		}
+		delete(values, key)
+	})
+}
+
+// updateConfigFile applies change to the file's values and saves them. The file may
+// hold the client secret, so it is only readable by the user.
+func updateConfigFile(change func(values map[string]any)) error {
+	path, err := ConfigFilePath()
+	if err != nil {
+		return err
+	}
+
+	configMu.Lock()
+	if fileErr != nil {
+		// Don't overwrite a file we failed to read
+		configMu.Unlock()
+		return fileErr
+	}
+	values := make(map[string]any, len(fileValues))
+	for k, v := range fileValues {
+		values[k] = v
+	}
+	change(values)
+
+	var buf bytes.Buffer
+	enc := yaml.NewEncoder(&buf)
+	enc.SetIndent(2)
+	err = enc.Encode(values)
+	if err == nil {
+		err = WriteFileAtomic(path, buf.Bytes(), 0600)
+	}
+	if err == nil {
+		fileValues = values
+	}
+	configMu.Unlock()
+
+	if err != nil {
+		return fmt.Errorf("could not write config file: %v", err)
+	}
+	return applyConfig()
+}
+
+func parseString(v string) (any, error) {
+	return v, nil
+}
+
+// parseTheme checks for a theme the TUI has; ThemeNames isn't filled in yet when
+// Settings is
+func parseTheme(v string) (any, error) {
+	return parseOneOf(ThemeNames...)(v)
+}
+
+func parseOneOf(allowed ...string) func(string) (any, error) {
+	return func(v string) (any, error) {
+		for _, a := range allowed {
+			if v == a {
+				return v, nil
+			}
+		}
+		return nil, fmt.Errorf("must be one of %s", strings.Join(allowed, ", "))
+	}
+}
+
+func parseIntRange(min, max int) func(string) (any, error) {
+	return func(v string) (any, error) {
+		n, err := strconv.Atoi(v)
+		if err != nil {
+			return nil, fmt.Errorf("%q is not a number", v)
+		}
+		if n < min || n > max {
+			return nil, fmt.Errorf("must be between %d and %d", min, max)
+		}
+		return n, nil
+	}
+}
+
+// parseMinDuration accepts Go durations such as "2s" or "500ms", no shorter than min.
+// They are stored as strings so the file stays readable.
+func parseMinDuration(min time.Duration) func(string) (any, error) {
+	return func(v string) (any, error) {
+		d, err := time.ParseDuration(v)
+		if err != nil {
+			return nil, fmt.Errorf("%q is not a duration like 5s", v)
+		}
+		if d < min {
+			return nil, fmt.Errorf("must be at least %s", min)
+		}
+		return d.String(), nil
+	}
+}
@@ -0,0 +1,387 @@
+// Credential storage: the OAuth token is kept either as plain JSON or encrypted with
+// AES-GCM, chosen with GITIFY_CREDENTIAL_STORE.
+
+package utils
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"sync"
+
+	"golang.org/x/crypto/scrypt"
+	"golang.org/x/term"
+)
+
+// Credential store backends selectable with GITIFY_CREDENTIAL_STORE
+const (
+	CredentialStoreFile      = "file"      // plaintext JSON, readable only by the user
+	CredentialStoreEncrypted = "encrypted" // AES-GCM, keyed by a passphrase or key file
+)
+
+// CredentialStore persists the OAuth token between runs. Load returns an error
+// matching fs.ErrNotExist when nothing has been saved yet.
+type CredentialStore interface {
+	Load() (*SpotfiyToken, error)
+	Save(token *SpotfiyToken) error
+	Delete() error
+	// Location describes where the token is kept, for messages
+	Location() string
+}
+
+// Credentials, when set, replaces the store picked from the environment
+var Credentials CredentialStore
+
+var (
+	// Stores opened so far, by Location, one per account and backend
+	openStores   = map[string]CredentialStore{}
+	openStoresMu sync.Mutex
+)
+
+// credentialStore returns Credentials or the store configured by the environment for
+// the active account. Each store is opened once per process so an encryption key is
+// only derived once, and switching accounts picks up the new account's store.
+func credentialStore() (CredentialStore, error) {
+	if Credentials != nil {
+		return Credentials, nil
+	}
+
+	store, err := OpenCredentialStore(os.Getenv("GITIFY_CREDENTIAL_STORE"))
+	if err != nil {
+		return nil, err
+	}
+
+	openStoresMu.Lock()
+	defer openStoresMu.Unlock()
+	if open, ok := openStores[store.Location()]; ok {
+		return open, nil
+	}
+	openStores[store.Location()] = store
+	return store, nil
+}
+
+// CredentialsLocation describes where the configured credential store keeps the token
+func CredentialsLocation() (string, error) {
+	store, err := credentialStore()
+	if err != nil {
+		return "", err
+	}
+	return store.Location(), nil
+}
+
+// OpenCredentialStore returns the backend named kind. An empty kind means encrypted
+// when GITIFY_PASSPHRASE or GITIFY_KEY_FILE is set, and plaintext otherwise.
+func OpenCredentialStore(kind string) (CredentialStore, error) {
+	if kind == "" {
+		kind = CredentialStoreFile
+		if os.Getenv("GITIFY_PASSPHRASE") != "" || os.Getenv("GITIFY_KEY_FILE") != "" {
+			kind = CredentialStoreEncrypted
+		}
+	}
+
+	plainPath, err := TokenPath()
+	if err != nil {
+		return nil, err
+	}
+
+	switch kind {
+	case CredentialStoreFile:
+		return &FileStore{Path: plainPath}, nil
+	case CredentialStoreEncrypted:
+		encPath, err := EncryptedTokenPath()
+		if err != nil {
+			return nil, err
+		}
+		return &EncryptedFileStore{
+			Path:   encPath,
+			Secret: credentialSecret,
+			Legacy: &FileStore{Path: plainPath},
+		}, nil
+	}
+	return nil, fmt.Errorf("unknown GITIFY_CREDENTIAL_STORE %q, use %q or %q", kind, CredentialStoreFile, CredentialStoreEncrypted)
+}
+
+// FileStore keeps the token as plain JSON, written atomically with 0600 permissions
+type FileStore struct {
+	Path string
+}
+
+func (s *FileStore) Location() string { return s.Path }
+
+func (s *FileStore) Load() (*SpotfiyToken, error) {
+	data, err := os.ReadFile(s.Path)
+	if err != nil {
+		return nil, err
+	}
+
+	var token SpotfiyToken
+	if err := json.Unmarshal(data, &token); err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %v", s.Path, err)
+	}
+	return &token, nil
+}
+
+func (s *FileStore) Save(token *SpotfiyToken) error {
+	data, err := json.MarshalIndent(token, "", "  ")
+	if err != nil {
+		return err
+	}
+	return WriteFileAtomic(s.Path, data, 0600)
+}
+
+func (s *FileStore) Delete() error {
+	if err := os.Remove(s.Path); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return err
+	}
+	return nil
+}
+
+// scrypt cost parameters for new files. They are stored alongside the ciphertext so
+// they can be raised later without breaking existing files.
+const (
+	scryptN      = 1 << 15
+	scryptR      = 8
+	scryptP      = 1
+	scryptKeyLen = 32 // AES-256
+)
+
+// encryptedFile is the on-disk format of EncryptedFileStore
+type encryptedFile struct {
+	Version    int    `json:"version"`
+	KDF        string `json:"kdf"`
+	N          int    `json:"n"`
+	R          int    `json:"r"`
+	P          int    `json:"p"`
+	Salt       []byte `json:"salt"`
+	Nonce      []byte `json:"nonce"`
+	Ciphertext []byte `json:"ciphertext"`
+}
+
+// EncryptedFileStore keeps the token encrypted with AES-256-GCM under a key derived
+// with scrypt from a passphrase or the contents of a key file.
+type EncryptedFileStore struct {
+	Path string
+	// Secret returns the passphrase or key file contents. confirm is true when a new
+	// file is about to be created, so an interactive prompt can ask twice.
+	Secret func(confirm bool) ([]byte, error)
+	// Legacy, if set, is a plaintext store that is encrypted into Path and deleted the
+	// first time Path does not exist yet.
+	Legacy *FileStore
+
+	mu      sync.Mutex
+	salt    []byte // salt and cost the cached key was derived with
+	n, r, p int
+	key     []byte
+}
+
+func (s *EncryptedFileStore) Location() string { return s.Path + " (encrypted)" }
+
+func (s *EncryptedFileStore) Load() (*SpotfiyToken, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	data, err := os.ReadFile(s.Path)
+	if errors.Is(err, fs.ErrNotExist) && s.Legacy != nil {
+		return s.importLegacy()
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var f encryptedFile
+	if err := json.Unmarshal(data, &f); err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %v", s.Path, err)
+	}
+	if f.Version != 1 || f.KDF != "scrypt" {
+		return nil, fmt.Errorf("%s uses an unsupported format (version %d, kdf %q)", s.Path, f.Version, f.KDF)
+	}
+
+	key, err := s.deriveKey(f.Salt, f.N, f.R, f.P, false)
+	if err != nil {
+		return nil, err
+	}
+	gcm, err := newGCM(key)
+	if err != nil {
+		return nil, err
+	}
+	plain, err := gcm.Open(nil, f.Nonce, f.Ciphertext, nil)
+	if err != nil {
+		// Don't keep a key that doesn't open the file
+		s.salt, s.key = nil, nil
+		return nil, ErrBadPassphrase
+	}
+
+	var token SpotfiyToken
+	if err := json.Unmarshal(plain, &token); err != nil {
+		return nil, fmt.Errorf("failed to parse decrypted %s: %v", s.Path, err)
+	}
+	return &token, nil
+}
+
+func (s *EncryptedFileStore) Save(token *SpotfiyToken) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.saveLocked(token)
+}
+
+func (s *EncryptedFileStore) saveLocked(token *SpotfiyToken) error {
+	plain, err := json.Marshal(token)
+	if err != nil {
+		return err
+	}
+
+	// Reuse the key from the last Load so refreshes don't prompt again
+	if s.key == nil {
+		salt := make([]byte, 16)
+		if _, err := rand.Read(salt); err != nil {
+			return err
+		}
+		_, statErr := os.Stat(s.Path)
+		if _, err := s.deriveKey(salt, scryptN, scryptR, scryptP, statErr != nil); err != nil {
+			return err
+		}
+	}
+	key := s.key
+
+	gcm, err := newGCM(key)
+	if err != nil {
+		return err
+	}
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return err
+	}
+
+	data, err := json.MarshalIndent(encryptedFile{
+		Version:    1,
+		KDF:        "scrypt",
+		N:          s.n,
+		R:          s.r,
+		P:          s.p,
+		Salt:       s.salt,
+		Nonce:      nonce,
+		Ciphertext: gcm.Seal(nil, nonce, plain, nil),
+	}, "", "  ")
+	if err != nil {
+		return err
+	}
+	return WriteFileAtomic(s.Path, data, 0600)
+}
+
+func (s *EncryptedFileStore) Delete() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.salt, s.key = nil, nil
+	if err := os.Remove(s.Path); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return err
+	}
+	return nil
+}
+
+// importLegacy encrypts a token left in plaintext by the file backend and removes the
+// plaintext copy. Callers must hold s.mu.
+func (s *EncryptedFileStore) importLegacy() (*SpotfiyToken, error) {
+	token, err := s.Legacy.Load()
+	if err != nil {
+		return nil, err
+	}
+	if err := s.saveLocked(token); err != nil {
+		return nil, fmt.Errorf("failed to encrypt %s: %w", s.Legacy.Path, err)
+	}
+	if err := s.Legacy.Delete(); err != nil {
+		return nil, err
+	}
+	fmt.Fprintf(os.Stderr, "Encrypted %s into %s\n", s.Legacy.Path, s.Path)
+	return token, nil
+}
+
+// deriveKey runs scrypt over the secret, reusing the cached key for the same salt.
+// Callers must hold s.mu.
+func (s *EncryptedFileStore) deriveKey(salt []byte, n, r, p int, confirm bool) ([]byte, error) {
+	if s.key != nil && bytes.Equal(s.salt, salt) && s.n == n && s.r == r && s.p == p {
+		return s.key, nil
+	}
+
+	secret, err := s.Secret(confirm)
+	if err != nil {
+		return nil, err
+	}
+	key, err := scrypt.Key(secret, salt, n, r, p, scryptKeyLen)
+	if err != nil {
+		return nil, err
+	}
+
+	s.salt, s.n, s.r, s.p, s.key = salt, n, r, p, key
+	return key, nil
+}
+
+func newGCM(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+// credentialSecret reads the encryption secret from the file named by GITIFY_KEY_FILE,
+// from GITIFY_PASSPHRASE, or by prompting on the terminal, in that order.
+func credentialSecret(confirm bool) ([]byte, error) {
+	if path := os.Getenv("GITIFY_KEY_FILE"); path != "" {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read key file: %v", err)
+		}
+		data = bytes.TrimSpace(data)
+		if len(data) == 0 {
+			return nil, fmt.Errorf("key file %s is empty", path)
+		}
+		return data, nil
+	}
+
+	if passphrase := os.Getenv("GITIFY_PASSPHRASE"); passphrase != "" {
+		return []byte(passphrase), nil
+	}
+
+	return promptPassphrase(confirm)
+}
+
+// NoPrompt disables the passphrase prompt, e.g. while completing a command line where
+// nobody is there to answer it
+var NoPrompt bool
+
+func promptPassphrase(confirm bool) ([]byte, error) {
+	fd := int(os.Stdin.Fd())
+	if NoPrompt || !term.IsTerminal(fd) {
+		return nil, fmt.Errorf("credentials are encrypted: set GITIFY_PASSPHRASE or GITIFY_KEY_FILE")
+	}
+
+	fmt.Fprint(os.Stderr, "Gitify passphrase: ")
+	passphrase, err := term.ReadPassword(fd)
+	fmt.Fprintln(os.Stderr)
+	if err != nil {
+		return nil, err
+	}
+	if len(passphrase) == 0 {
+		return nil, fmt.Errorf("passphrase must not be empty")
+	}
+
+	if confirm {
+		fmt.Fprint(os.Stderr, "Repeat passphrase: ")
+		again, err := term.ReadPassword(fd)
+		fmt.Fprintln(os.Stderr)
+		if err != nil {
+			return nil, err
+		}
+		if !bytes.Equal(passphrase, again) {
+			return nil, fmt.Errorf("passphrases did not match")
+		}
+	}
+	return passphrase, nil
+}
@@ -0,0 +1,152 @@
+package utils
+
+import (
+	"bytes"
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+var testToken = &SpotfiyToken{
+	AccessToken:  "access-secret",
+	TokenType:    "Bearer",
+	RefreshToken: "refresh-secret",
+	Scope:        "user-read-private",
+	ExpiresIn:    3600,
+}
+
+// passphrase is an EncryptedFileStore Secret that always answers p
+func passphrase(p string) func(bool) ([]byte, error) {
+	return func(bool) ([]byte, error) { return []byte(p), nil }
+}
+
+func TestEncryptedFileStoreRoundTrip(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "token.enc")
+	if err := (&EncryptedFileStore{Path: path, Secret: passphrase("hunter2")}).Save(testToken); err != nil {
+		t.Fatal(err)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if bytes.Contains(data, []byte(testToken.AccessToken)) || bytes.Contains(data, []byte(testToken.RefreshToken)) {
+		t.Error("token is readable in the encrypted file")
+	}
+
+	// A fresh store has to derive the key again
+	got, err := (&EncryptedFileStore{Path: path, Secret: passphrase("hunter2")}).Load()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if *got != *testToken {
+		t.Errorf("loaded %+v, want %+v", got, testToken)
+	}
+}
+
+func TestEncryptedFileStoreWrongPassphrase(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "token.enc")
+	if err := (&EncryptedFileStore{Path: path, Secret: passphrase("hunter2")}).Save(testToken); err != nil {
+		t.Fatal(err)
+	}
+
+	_, err := (&EncryptedFileStore{Path: path, Secret: passphrase("hunter3")}).Load()
+	if !errors.Is(err, ErrBadPassphrase) {
+		t.Errorf("Load with the wrong passphrase: %v, want ErrBadPassphrase", err)
+	}
+}
+
+func TestEncryptedFileStoreKeyFile(t *testing.T) {
+	dir := t.TempDir()
+	keyFile := filepath.Join(dir, "key")
+	if err := os.WriteFile(keyFile, []byte("  key file secret\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("GITIFY_CONFIG_DIR", dir)
+	t.Setenv("GITIFY_KEY_FILE", keyFile)
+	t.Setenv("GITIFY_PASSPHRASE", "")
+
+	// A key file alone picks the encrypted store
+	store, err := OpenCredentialStore("")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := store.(*EncryptedFileStore); !ok {
+		t.Fatalf("OpenCredentialStore picked %T with GITIFY_KEY_FILE set", store)
+	}
+	if err := store.Save(testToken); err != nil {
+		t.Fatal(err)
+	}
+
+	// The key is the file's contents without surrounding whitespace
+	path, err := EncryptedTokenPath()
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := (&EncryptedFileStore{Path: path, Secret: passphrase("key file secret")}).Load()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if *got != *testToken {
+		t.Errorf("loaded %+v, want %+v", got, testToken)
+	}
+}
+
+func TestEncryptedFileStoreImportsLegacyToken(t *testing.T) {
+	dir := t.TempDir()
+	legacy := &FileStore{Path: filepath.Join(dir, "token.json")}
+	if err := legacy.Save(testToken); err != nil {
+		t.Fatal(err)
+	}
+	store := &EncryptedFileStore{Path: filepath.Join(dir, "token.enc"), Secret: passphrase("hunter2"), Legacy: legacy}
+
+	got, err := store.Load()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if *got != *testToken {
+		t.Errorf("imported %+v, want %+v", got, testToken)
+	}
+	if _, err := os.Stat(legacy.Path); !errors.Is(err, fs.ErrNotExist) {
+		t.Errorf("plaintext token left behind: %v", err)
+	}
+
+	got, err = (&EncryptedFileStore{Path: store.Path, Secret: passphrase("hunter2")}).Load()
+	if err != nil {
+		t.Fatalf("loading the imported token: %v", err)
+	}
+	if *got != *testToken {
+		t.Errorf("loaded %+v, want %+v", got, testToken)
+	}
+}
+
+func TestCredentialStoreFollowsAccountSwitch(t *testing.T) {
+	t.Setenv("GITIFY_CONFIG_DIR", t.TempDir())
+	t.Setenv("GITIFY_CREDENTIAL_STORE", "")
+	t.Setenv("GITIFY_KEY_FILE", "")
+	t.Setenv("GITIFY_PASSPHRASE", "")
+	t.Setenv("GITIFY_ACCOUNT", "")
+	t.Cleanup(func() { AccountOverride = "" })
+
+	work := *testToken
+	work.AccessToken = "work-secret"
+	for account, token := range map[string]*SpotfiyToken{DefaultAccount: testToken, "work": &work} {
+		AccountOverride = account
+		if err := saveToken(token); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	for account, want := range map[string]string{DefaultAccount: testToken.AccessToken, "work": work.AccessToken} {
+		AccountOverride = account
+		got, err := loadToken()
+		if err != nil {
+			t.Fatalf("%s: %v", account, err)
+		}
+		if got.AccessToken != want {
+			t.Errorf("%s: loaded access token %q, want %q", account, got.AccessToken, want)
+		}
+	}
+}
@@ -0,0 +1,107 @@
+package utils
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+)
+
+// Errors callers can match with errors.Is to decide what to tell the user
+var (
+	ErrNoActiveDevice    = errors.New("no active Spotify device found")
+	ErrNotFound          = errors.New("not found on Spotify")
+	ErrPremiumRequired   = errors.New("this action requires Spotify Premium")
+	ErrRateLimited       = errors.New("rate limited by Spotify")
+	ErrUnauthorized      = errors.New("not authorized, please login again")
+	ErrStateMismatch     = errors.New("login state did not match, please start the login again")
+	ErrBadPassphrase     = errors.New("could not decrypt credentials: wrong passphrase or key file")
+	ErrInsufficientScope = errors.New("the login does not grant a permission this needs")
+)
+
+// AuthorizeError is an error Spotify sent back to the login callback, for example
+// "access_denied" when the user declines the consent screen.
+type AuthorizeError struct {
+	Code string
+}
+
+func (e *AuthorizeError) Error() string {
+	if e.Code == "access_denied" {
+		return "access to Spotify was denied on the consent screen"
+	}
+	return "spotify authorization failed: " + e.Code
+}
+
+// APIError is a non-2xx response from the Web API, parsed from Spotify's
+// {"error": {"status": .., "message": .., "reason": ..}} body.
+type APIError struct {
+	StatusCode int
+	Message    string
+	Reason     string
+	// Path is the request path, e.g. "/v1/me/player/play"
+	Path string
+}
+
+func (e *APIError) Error() string {
+	if e.Message == "" {
+		return fmt.Sprintf("spotify returned status %d", e.StatusCode)
+	}
+	return fmt.Sprintf("spotify returned status %d: %s", e.StatusCode, e.Message)
+}
+
+// Unwrap maps the response onto one of the sentinel errors above, if any applies
+func (e *APIError) Unwrap() error {
+	msg := strings.ToLower(e.Message)
+	switch {
+	case e.Reason == "NO_ACTIVE_DEVICE",
+		e.StatusCode == http.StatusNotFound && strings.Contains(msg, "active device"),
+		// A bare 404 from the player means nothing is playing; elsewhere it is just missing
+		e.StatusCode == http.StatusNotFound && msg == "" && strings.Contains(e.Path, "/me/player"):
+		return ErrNoActiveDevice
+	case e.StatusCode == http.StatusNotFound:
+		return ErrNotFound
+	case e.Reason == "PREMIUM_REQUIRED",
+		e.StatusCode == http.StatusForbidden && strings.Contains(msg, "premium"):
+		return ErrPremiumRequired
+	case e.StatusCode == http.StatusForbidden && strings.Contains(msg, "scope"):
+		return ErrInsufficientScope
+	case e.StatusCode == http.StatusTooManyRequests:
+		return ErrRateLimited
+	case e.StatusCode == http.StatusUnauthorized:
+		return ErrUnauthorized
+	}
+	return nil
+}
+
+// Unwrap lets errors.Is(err, ErrRateLimited) match an exhausted 429 retry budget
+func (e *RetryError) Unwrap() error {
+	if e.StatusCode == http.StatusTooManyRequests {
+		return ErrRateLimited
+	}
+	return nil
+}
+
+// parseAPIError builds an APIError from a response, tolerating empty or non-JSON bodies
+func parseAPIError(resp *http.Response) *APIError {
+	apiErr := &APIError{StatusCode: resp.StatusCode}
+	if resp.Request != nil {
+		apiErr.Path = resp.Request.URL.Path
+	}
+
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, 4096))
+	var parsed struct {
+		Error struct {
+			Message string `json:"message"`
+			Reason  string `json:"reason"`
+		} `json:"error"`
+	}
+	if json.Unmarshal(body, &parsed) == nil {
+		apiErr.Message = parsed.Error.Message
+		apiErr.Reason = parsed.Error.Reason
+	} else {
+		apiErr.Message = strings.TrimSpace(string(body))
+	}
+	return apiErr
+}
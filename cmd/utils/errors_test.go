@@ -0,0 +1,32 @@
+package utils
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestAPIErrorUnwrap(t *testing.T) {
+	tests := []struct {
+		name string
+		err  APIError
+		want error
+	}{
+		{"no active device reason", APIError{StatusCode: 404, Message: "Player command failed: No active device found", Reason: "NO_ACTIVE_DEVICE", Path: "/v1/me/player/play"}, ErrNoActiveDevice},
+		{"active device message", APIError{StatusCode: 404, Message: "No active device found", Path: "/v1/me/player/next"}, ErrNoActiveDevice},
+		{"bare player 404", APIError{StatusCode: 404, Path: "/v1/me/player/pause"}, ErrNoActiveDevice},
+		{"bare playlist 404", APIError{StatusCode: 404, Path: "/v1/playlists/missing/tracks"}, ErrNotFound},
+		{"missing album", APIError{StatusCode: 404, Message: "Non existing id", Path: "/v1/albums/missing"}, ErrNotFound},
+		{"premium", APIError{StatusCode: 403, Message: "Player command failed: Premium required", Reason: "PREMIUM_REQUIRED"}, ErrPremiumRequired},
+		{"scope", APIError{StatusCode: 403, Message: "Insufficient client scope"}, ErrInsufficientScope},
+		{"rate limited", APIError{StatusCode: 429}, ErrRateLimited},
+		{"unauthorized", APIError{StatusCode: 401, Message: "The access token expired"}, ErrUnauthorized},
+		{"server error", APIError{StatusCode: 500}, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.err.Unwrap(); !errors.Is(got, tt.want) || (tt.want == nil && got != nil) {
+				t.Errorf("Unwrap() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,204 @@
+// Login bookkeeping: every authorize URL we hand out gets a random state, and the
+// callback only accepts codes that come back with one of those states.
+
+package utils
+
+import (
+	"context"
+	"fmt"
+	"html/template"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+// loginStateTTL is how long a started login may take before its state is rejected
+const loginStateTTL = 10 * time.Minute
+
+// pendingLogin is an authorize request we sent and are waiting to hear back about
+type pendingLogin struct {
+	verifier  string // PKCE code_verifier, empty for the code flow
+	createdAt time.Time
+}
+
+var (
+	pendingLogins   = map[string]pendingLogin{}
+	pendingLoginsMu sync.Mutex
+)
+
+// AuthorizeURL builds the Spotify consent URL for a new login and remembers its state
+// (and PKCE verifier) so the callback can be validated.
+func AuthorizeURL() (string, error) {
+	state, err := generateRandomString(16)
+	if err != nil {
+		return "", fmt.Errorf("failed to generate login state: %v", err)
+	}
+
+	authURL, err := url.Parse(AccountsBaseURL + "/authorize")
+	if err != nil {
+		return "", err
+	}
+	params := url.Values{}
+	params.Add("client_id", Client_ID)
+	params.Add("response_type", "code")
+	params.Add("redirect_uri", RedirectUrl)
+	params.Add("scope", strings.Join(LoginScopes(), " "))
+	params.Add("state", state)
+
+	login := pendingLogin{createdAt: time.Now()}
+	if usePKCE() {
+		verifier, err := generateCodeVerifier()
+		if err != nil {
+			return "", fmt.Errorf("failed to generate code verifier: %v", err)
+		}
+		login.verifier = verifier
+		params.Add("code_challenge_method", "S256")
+		params.Add("code_challenge", codeChallenge(verifier))
+	}
+
+	pendingLoginsMu.Lock()
+	pendingLogins[state] = login
+	pendingLoginsMu.Unlock()
+
+	authURL.RawQuery = params.Encode()
+	return authURL.String(), nil
+}
+
+// takePendingLogin returns and forgets the login started with state. States are
+// single use and expire after loginStateTTL.
+func takePendingLogin(state string) (pendingLogin, bool) {
+	pendingLoginsMu.Lock()
+	defer pendingLoginsMu.Unlock()
+
+	login, ok := pendingLogins[state]
+	delete(pendingLogins, state)
+	if !ok || time.Since(login.createdAt) > loginStateTTL {
+		return pendingLogin{}, false
+	}
+	return login, true
+}
+
+// CompleteLogin checks state against a login started by AuthorizeURL, exchanges the
+// code for tokens and saves them.
+func CompleteLogin(ctx context.Context, code, state string) error {
+	login, ok := takePendingLogin(state)
+	if !ok {
+		return ErrStateMismatch
+	}
+	if code == "" {
+		return fmt.Errorf("no authorization code in the callback")
+	}
+
+	token, err := exchangeToken(ctx, code, login.verifier)
+	if err != nil {
+		return fmt.Errorf("failed to exchange token: %v", err)
+	}
+
+	if err := saveToken(token); err != nil {
+		return fmt.Errorf("failed to write token to file: %v", err)
+	}
+	return nil
+}
+
+// ParseRedirect extracts the code and state from what a user pasted after a headless
+// login: either the full URL Spotify redirected to, or just the code. A bare code
+// returns an empty state.
+func ParseRedirect(input string) (code, state string, err error) {
+	input = strings.TrimSpace(input)
+	if input == "" {
+		return "", "", fmt.Errorf("nothing was pasted")
+	}
+
+	u, err := url.Parse(input)
+	if err != nil || u.RawQuery == "" {
+		return input, "", nil
+	}
+
+	q := u.Query()
+	if e := q.Get("error"); e != "" {
+		takePendingLogin(q.Get("state"))
+		return "", "", &AuthorizeError{Code: e}
+	}
+	if q.Get("code") == "" {
+		return "", "", fmt.Errorf("no code found in the pasted URL")
+	}
+	return q.Get("code"), q.Get("state"), nil
+}
+
+// NewCallbackHandler handles the redirect back from Spotify: it reports a denied
+// consent, completes the login and renders a result page. A request whose state
+// doesn't belong to a login in progress (a stray or forged request, a browser
+// prefetch) gets a 400 and is otherwise ignored. done, if not nil, is called with
+// the outcome (nil on success) of each request that did carry a pending state.
+func NewCallbackHandler(done func(error)) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		q := r.URL.Query()
+
+		var err error
+		status := http.StatusOK
+		if code := q.Get("error"); code != "" {
+			// Spotify still echoes the state; drop it so it can't be reused
+			if _, ok := takePendingLogin(q.Get("state")); !ok {
+				renderLoginPage(w, http.StatusBadRequest, ErrStateMismatch)
+				return
+			}
+			err = &AuthorizeError{Code: code}
+			status = http.StatusForbidden
+		} else {
+			// Finish the exchange even if the browser goes away mid-request
+			err = CompleteLogin(context.WithoutCancel(r.Context()), q.Get("code"), q.Get("state"))
+			if err == ErrStateMismatch {
+				renderLoginPage(w, http.StatusBadRequest, err)
+				return
+			}
+			if err != nil {
+				status = http.StatusInternalServerError
+			}
+		}
+
+		renderLoginPage(w, status, err)
+		if done != nil {
+			done(err)
+		}
+	}
+}
+
+var loginPage = template.Must(template.New("login").Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>Gitify login</title>
+<style>
+body { background: #121212; color: #fff; font-family: sans-serif; display: flex; align-items: center; justify-content: center; height: 100vh; margin: 0; }
+.card { background: #181818; border-radius: 12px; padding: 2em 3em; text-align: center; }
+h1 { color: {{if .Err}}#E91E63{{else}}#1DB954{{end}}; }
+p { color: #B3B3B3; }
+</style>
+</head>
+<body>
+<div class="card">
+{{if .Err}}
+<h1>Login failed</h1>
+<p>{{.Err}}</p>
+<p>Return to your terminal and run <code>gitify spotify login</code> again.</p>
+{{else}}
+<h1>Login successful!</h1>
+<p>You can close this tab and return to Gitify.</p>
+{{end}}
+</div>
+</body>
+</html>
+`))
+
+func renderLoginPage(w http.ResponseWriter, status int, err error) {
+	data := struct{ Err string }{}
+	if err != nil {
+		data.Err = err.Error()
+	}
+
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.WriteHeader(status)
+	loginPage.Execute(w, data)
+}
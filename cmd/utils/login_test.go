@@ -0,0 +1,155 @@
+package utils
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/adi-253/Gitify/cmd/fakespotify"
+)
+
+// useFakeAccounts points the login flow at a fake accounts service and keeps the token
+// in a temporary file, restoring everything when the test ends
+func useFakeAccounts(t *testing.T) *FileStore {
+	t.Helper()
+	srv := httptest.NewServer(fakespotify.New())
+	store := &FileStore{Path: filepath.Join(t.TempDir(), "token.json")}
+
+	oldBase, oldID, oldFlow, oldRedirect, oldStore := AccountsBaseURL, Client_ID, AuthFlow, RedirectUrl, Credentials
+	AccountsBaseURL, Client_ID, AuthFlow, RedirectUrl, Credentials = srv.URL, "test-client", AuthFlowPKCE, "http://127.0.0.1:8080/callback", store
+	t.Cleanup(func() {
+		srv.Close()
+		AccountsBaseURL, Client_ID, AuthFlow, RedirectUrl, Credentials = oldBase, oldID, oldFlow, oldRedirect, oldStore
+	})
+	return store
+}
+
+// startLogin goes through the fake consent screen and returns the query Spotify
+// would send to the callback
+func startLogin(t *testing.T) url.Values {
+	t.Helper()
+	authURL, err := AuthorizeURL()
+	if err != nil {
+		t.Fatal(err)
+	}
+	client := &http.Client{CheckRedirect: func(*http.Request, []*http.Request) error {
+		return http.ErrUseLastResponse
+	}}
+	resp, err := client.Get(authURL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+
+	redirect, err := url.Parse(resp.Header.Get("Location"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return redirect.Query()
+}
+
+// callback sends query to a callback handler and returns the response status and
+// the outcomes passed to done
+func callback(query url.Values) (int, []error) {
+	var outcomes []error
+	handler := NewCallbackHandler(func(err error) { outcomes = append(outcomes, err) })
+	rec := httptest.NewRecorder()
+	handler(rec, httptest.NewRequest(http.MethodGet, "/callback?"+query.Encode(), nil))
+	return rec.Code, outcomes
+}
+
+func TestCallbackHandlerGoodState(t *testing.T) {
+	store := useFakeAccounts(t)
+	query := startLogin(t)
+
+	status, outcomes := callback(query)
+	if status != http.StatusOK {
+		t.Errorf("status = %d, want %d", status, http.StatusOK)
+	}
+	if len(outcomes) != 1 || outcomes[0] != nil {
+		t.Fatalf("done called with %v, want one nil", outcomes)
+	}
+	token, err := store.Load()
+	if err != nil {
+		t.Fatalf("token not saved: %v", err)
+	}
+	if token.AccessToken == "" || token.RefreshToken == "" {
+		t.Errorf("saved token is missing tokens: %+v", token)
+	}
+
+	// The state is used up, so replaying the callback is turned away
+	if status, outcomes := callback(query); status != http.StatusBadRequest || len(outcomes) != 0 {
+		t.Errorf("replay: status %d, done called with %v; want 400 and no call", status, outcomes)
+	}
+}
+
+func TestCallbackHandlerMismatchedState(t *testing.T) {
+	store := useFakeAccounts(t)
+	query := startLogin(t)
+	good := query.Get("state")
+	query.Set("state", "not-"+good)
+
+	status, outcomes := callback(query)
+	if status != http.StatusBadRequest {
+		t.Errorf("status = %d, want %d", status, http.StatusBadRequest)
+	}
+	if len(outcomes) != 0 {
+		t.Errorf("done called with %v for a stray request", outcomes)
+	}
+	if _, err := store.Load(); err == nil {
+		t.Error("token saved for a mismatched state")
+	}
+
+	// The real login is still waiting and can finish
+	query.Set("state", good)
+	if status, outcomes := callback(query); status != http.StatusOK || len(outcomes) != 1 || outcomes[0] != nil {
+		t.Errorf("after a stray request: status %d, done called with %v; want 200 and nil", status, outcomes)
+	}
+}
+
+func TestCallbackHandlerAccessDenied(t *testing.T) {
+	useFakeAccounts(t)
+	query := startLogin(t)
+	denied := url.Values{"error": {"access_denied"}, "state": {query.Get("state")}}
+
+	status, outcomes := callback(denied)
+	if status != http.StatusForbidden {
+		t.Errorf("status = %d, want %d", status, http.StatusForbidden)
+	}
+	if len(outcomes) != 1 {
+		t.Fatalf("done called %d times, want once", len(outcomes))
+	}
+	authErr, ok := outcomes[0].(*AuthorizeError)
+	if !ok || authErr.Code != "access_denied" {
+		t.Errorf("done called with %v, want an access_denied AuthorizeError", outcomes[0])
+	}
+
+	// A denial without a pending state is just another stray request
+	denied.Set("state", "unknown")
+	if status, outcomes := callback(denied); status != http.StatusBadRequest || len(outcomes) != 0 {
+		t.Errorf("unknown state: status %d, done called with %v; want 400 and no call", status, outcomes)
+	}
+}
+
+func TestCallbackHandlerTimeout(t *testing.T) {
+	useFakeAccounts(t)
+	query := startLogin(t)
+
+	// Age the login past loginStateTTL
+	pendingLoginsMu.Lock()
+	login := pendingLogins[query.Get("state")]
+	login.createdAt = time.Now().Add(-loginStateTTL - time.Minute)
+	pendingLogins[query.Get("state")] = login
+	pendingLoginsMu.Unlock()
+
+	status, outcomes := callback(query)
+	if status != http.StatusBadRequest {
+		t.Errorf("status = %d, want %d", status, http.StatusBadRequest)
+	}
+	if len(outcomes) != 0 {
+		t.Errorf("done called with %v for an expired login", outcomes)
+	}
+}
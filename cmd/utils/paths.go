@@ -0,0 +1,137 @@
+// Where Gitify keeps its files: $XDG_CONFIG_HOME/gitify (or the OS equivalent),
+// overridable with --config-dir or GITIFY_CONFIG_DIR.
+
+package utils
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+)
+
+// ConfigDirOverride is set from the --config-dir flag and wins over everything else
+var ConfigDirOverride string
+
+// ConfigDir returns Gitify's config directory, creating it with 0700 permissions.
+func ConfigDir() (string, error) {
+	dir := ConfigDirOverride
+	if dir == "" {
+		dir = os.Getenv("GITIFY_CONFIG_DIR")
+	}
+	if dir == "" {
+		base, err := os.UserConfigDir()
+		if err != nil {
+			return "", fmt.Errorf("could not find a config directory, set GITIFY_CONFIG_DIR: %v", err)
+		}
+		dir = filepath.Join(base, "gitify")
+	}
+
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return "", fmt.Errorf("could not create config directory %s: %v", dir, err)
+	}
+	return dir, nil
+}
+
+// TokenPath is where the active account's OAuth token is stored
+func TokenPath() (string, error) {
+	return AccountFile(ActiveAccount(), "token.json")
+}
+
+// EncryptedTokenPath is where the encrypted credential store keeps the active account's token
+func EncryptedTokenPath() (string, error) {
+	return AccountFile(ActiveAccount(), "token.enc")
+}
+
+// ProfilePath is where the active account's cached Spotify profile is stored
+func ProfilePath() (string, error) {
+	return AccountFile(ActiveAccount(), "profile.json")
+}
+
+// WriteFileAtomic writes to a temp file in the same directory and renames it over
+// name, so readers never see a half-written file. Missing directories are created 0700.
+func WriteFileAtomic(name string, data []byte, perm os.FileMode) error {
+	if err := os.MkdirAll(filepath.Dir(name), 0700); err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(name), "."+filepath.Base(name)+".tmp-*")
+	if err != nil {
+		return err
+	}
+	tmpName := tmp.Name()
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		os.Remove(tmpName)
+		return err
+	}
+	if err := tmp.Chmod(perm); err != nil {
+		tmp.Close()
+		os.Remove(tmpName)
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmpName)
+		return err
+	}
+	if err := os.Rename(tmpName, name); err != nil {
+		os.Remove(tmpName)
+		return err
+	}
+	return nil
+}
+
+// MigrateLegacyFiles moves the token.json and profile.json older versions wrote to the
+// working directory into the default account. It runs before any command reads them,
+// and only when the default account has no token yet and the file really holds a
+// Spotify token; files already present in the config directory are never overwritten.
+func MigrateLegacyFiles() {
+	if ActiveAccount() != DefaultAccount || !isLegacyToken("token.json") {
+		return
+	}
+	dir, err := ConfigDir()
+	if err != nil {
+		return
+	}
+	for _, name := range []string{"token.json", "token.enc"} {
+		if _, err := os.Stat(filepath.Join(dir, name)); err == nil {
+			return
+		}
+	}
+
+	for _, name := range []string{"token.json", "profile.json"} {
+		dst := filepath.Join(dir, name)
+		if _, err := os.Stat(dst); err == nil {
+			continue
+		}
+
+		data, err := os.ReadFile(name)
+		if errors.Is(err, fs.ErrNotExist) {
+			continue
+		}
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Could not migrate %s: %v\n", name, err)
+			continue
+		}
+
+		if err := WriteFileAtomic(dst, data, 0600); err != nil {
+			fmt.Fprintf(os.Stderr, "Could not migrate %s: %v\n", name, err)
+			continue
+		}
+		os.Remove(name)
+		fmt.Fprintf(os.Stderr, "Moved %s from an older version of Gitify to %s\n", name, dst)
+	}
+}
+
+// isLegacyToken reports whether path holds a token saved by an older version, rather
+// than some other project's token.json
+func isLegacyToken(path string) bool {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return false
+	}
+	var token SpotfiyToken
+	return json.Unmarshal(data, &token) == nil && token.AccessToken != "" && token.RefreshToken != ""
+}
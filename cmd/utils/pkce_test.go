@@ -0,0 +1,32 @@
+package utils
+
+import (
+	"regexp"
+	"testing"
+)
+
+// RFC 7636 Appendix B
+func TestCodeChallengeRFC7636(t *testing.T) {
+	verifier := "dBjftJeZ4CVP-mB92K27uhbUJU1p1r_wW1gFWFOEjXk"
+	if got, want := codeChallenge(verifier), "E9Melhoa2OwvFrEMTJguCHaoeK1t8URWbuGJSstw-cM"; got != want {
+		t.Errorf("codeChallenge(%q) = %q, want %q", verifier, got, want)
+	}
+}
+
+func TestGenerateCodeVerifier(t *testing.T) {
+	unreserved := regexp.MustCompile(`^[A-Za-z0-9._~-]{43,128}$`)
+	a, err := generateCodeVerifier()
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, err := generateCodeVerifier()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !unreserved.MatchString(a) {
+		t.Errorf("verifier %q is not 43-128 unreserved characters", a)
+	}
+	if a == b {
+		t.Error("two verifiers are the same")
+	}
+}
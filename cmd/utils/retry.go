@@ -0,0 +1,94 @@
+package utils
+
+import (
+	"fmt"
+	"math/rand/v2"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// RetryPolicy controls how requests are retried when Spotify rate limits us (429)
+// or a gateway in front of it fails transiently (502/503/504). Gateway errors are
+// only retried for idempotent methods, since Spotify may already have acted on a POST.
+type RetryPolicy struct {
+	MaxRetries int           // retries after the first attempt
+	BaseDelay  time.Duration // first backoff for 5xx, doubled on each retry
+	MaxDelay   time.Duration // upper bound for any single wait, including Retry-After
+}
+
+// DefaultRetryPolicy is used by NewSpotifyClient
+var DefaultRetryPolicy = RetryPolicy{
+	MaxRetries: 4,
+	BaseDelay:  500 * time.Millisecond,
+	MaxDelay:   30 * time.Second,
+}
+
+// RetryError is returned once a request keeps failing with a retryable status
+// and the retry budget is exhausted.
+type RetryError struct {
+	StatusCode int
+	Attempts   int
+	// RetryAfter is the last wait the server asked for, zero if it sent none
+	RetryAfter time.Duration
+}
+
+func (e *RetryError) Error() string {
+	if e.StatusCode == http.StatusTooManyRequests {
+		msg := fmt.Sprintf("rate limited by Spotify after %d attempts", e.Attempts)
+		if e.RetryAfter > 0 {
+			msg += fmt.Sprintf(", retry after %s", e.RetryAfter)
+		}
+		return msg
+	}
+	return fmt.Sprintf("spotify returned status %d after %d attempts", e.StatusCode, e.Attempts)
+}
+
+// isRetryable reports whether a request that got code may be sent again. A 429 was
+// rejected before Spotify acted on it; a gateway error may have come after, so a
+// POST such as next or add-to-queue is not repeated.
+func isRetryable(method string, code int) bool {
+	switch code {
+	case http.StatusTooManyRequests:
+		return true
+	case http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		return isIdempotent(method)
+	}
+	return false
+}
+
+func isIdempotent(method string) bool {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodPut, http.MethodDelete:
+		return true
+	}
+	return false
+}
+
+// retryAfter parses the Retry-After header, which Spotify sends in seconds; an HTTP
+// date is accepted too.
+func retryAfter(resp *http.Response) time.Duration {
+	v := resp.Header.Get("Retry-After")
+	if v == "" {
+		return 0
+	}
+	if secs, err := strconv.Atoi(v); err == nil && secs >= 0 {
+		return time.Duration(secs) * time.Second
+	}
+	if t, err := http.ParseTime(v); err == nil {
+		// A date already past means retry now
+		return max(time.Until(t), 0)
+	}
+	return 0
+}
+
+// backoff returns an exponential delay with jitter for the given retry number (0-based),
+// picked uniformly from the upper half of the window so waits never collapse to zero.
+func (p RetryPolicy) backoff(attempt int) time.Duration {
+	d := p.BaseDelay << attempt
+	if d <= 0 || d > p.MaxDelay {
+		d = p.MaxDelay
+	}
+	half := d / 2
+	return half + rand.N(half+1)
+}
@@ -0,0 +1,122 @@
+package utils
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestRetryAfter(t *testing.T) {
+	tests := []struct {
+		name   string
+		header string
+		min    time.Duration
+		max    time.Duration
+	}{
+		{"missing", "", 0, 0},
+		{"seconds", "3", 3 * time.Second, 3 * time.Second},
+		{"zero", "0", 0, 0},
+		{"negative", "-5", 0, 0},
+		{"future date", time.Now().Add(10 * time.Second).UTC().Format(http.TimeFormat), 8 * time.Second, 10 * time.Second},
+		{"past date", time.Now().Add(-time.Hour).UTC().Format(http.TimeFormat), 0, 0},
+		{"garbage", "soon", 0, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp := &http.Response{Header: http.Header{}}
+			if tt.header != "" {
+				resp.Header.Set("Retry-After", tt.header)
+			}
+			if got := retryAfter(resp); got < tt.min || got > tt.max {
+				t.Errorf("retryAfter(%q) = %v, want %v to %v", tt.header, got, tt.min, tt.max)
+			}
+		})
+	}
+}
+
+func TestBackoff(t *testing.T) {
+	p := RetryPolicy{BaseDelay: 100 * time.Millisecond, MaxDelay: time.Second}
+	for attempt, window := range []time.Duration{100 * time.Millisecond, 200 * time.Millisecond, 400 * time.Millisecond, 800 * time.Millisecond, time.Second, time.Second} {
+		for range 20 {
+			if d := p.backoff(attempt); d < window/2 || d > window {
+				t.Fatalf("backoff(%d) = %v, want %v to %v", attempt, d, window/2, window)
+			}
+		}
+	}
+	// Shifting far enough overflows; the wait still stays capped
+	if d := p.backoff(70); d < p.MaxDelay/2 || d > p.MaxDelay {
+		t.Errorf("backoff(70) = %v, want at most %v", d, p.MaxDelay)
+	}
+}
+
+// retryServer answers the first len(statuses) requests with those statuses, and 204
+// after that, counting every request
+func retryServer(t *testing.T, retryAfter int, statuses ...int) (*SpotifyClient, *atomic.Int32) {
+	t.Helper()
+	var requests atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := int(requests.Add(1))
+		if n > len(statuses) {
+			w.WriteHeader(http.StatusNoContent)
+			return
+		}
+		if statuses[n-1] == http.StatusTooManyRequests {
+			w.Header().Set("Retry-After", strconv.Itoa(retryAfter))
+		}
+		w.WriteHeader(statuses[n-1])
+	}))
+	t.Cleanup(srv.Close)
+
+	return &SpotifyClient{
+		HTTPClient: srv.Client(),
+		Token:      &SpotfiyToken{AccessToken: "access"},
+		APIBaseURL: srv.URL,
+		Retry:      RetryPolicy{MaxRetries: 2, BaseDelay: time.Millisecond, MaxDelay: 50 * time.Millisecond},
+	}, &requests
+}
+
+func TestMakeRequestRetries(t *testing.T) {
+	tests := []struct {
+		name       string
+		method     string
+		retryAfter int
+		statuses   []int
+		requests   int32
+		wantStatus int // of the response handed back, 0 for an error
+	}{
+		{"get retried on 503", http.MethodGet, 0, []int{503, 502}, 3, 204},
+		{"put retried on 504", http.MethodPut, 0, []int{504}, 2, 204},
+		{"post not retried on 503", http.MethodPost, 0, []int{503}, 1, 503},
+		{"post retried on 429", http.MethodPost, 0, []int{429}, 2, 204},
+		{"budget exhausted", http.MethodGet, 0, []int{503, 503, 503}, 3, 0},
+		{"retry-after past max delay", http.MethodGet, 60, []int{429}, 1, 0},
+		{"client error not retried", http.MethodGet, 0, []int{404}, 1, 404},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client, requests := retryServer(t, tt.retryAfter, tt.statuses...)
+			resp, err := client.makeRequest(context.Background(), tt.method, client.APIURL("/me/player/next"), nil)
+			if tt.wantStatus == 0 {
+				var retryErr *RetryError
+				if !errors.As(err, &retryErr) {
+					t.Errorf("err = %v, want a RetryError", err)
+				}
+			} else if err != nil {
+				t.Fatalf("err = %v, want status %d", err, tt.wantStatus)
+			} else {
+				resp.Body.Close()
+				if resp.StatusCode != tt.wantStatus {
+					t.Errorf("status %d, want %d", resp.StatusCode, tt.wantStatus)
+				}
+			}
+			if got := requests.Load(); got != tt.requests {
+				t.Errorf("sent %d requests, want %d", got, tt.requests)
+			}
+		})
+	}
+}
@@ -0,0 +1,76 @@
+// OAuth scopes: every login asks for DefaultScopes, plus whatever commands that need
+// more have added, so extra permissions are only requested once a feature needs them.
+
+package utils
+
+import (
+	"strings"
+	"sync"
+)
+
+// DefaultScopes are requested on every login: the profile, which login itself reads,
+// and the read access most commands use. Commands declare the rest, such as control
+// of playback, and ask for them the first time they run.
+var DefaultScopes = []string{
+	"user-read-private",
+	"user-read-email",
+	"playlist-read-private",
+	"user-read-playback-state",
+}
+
+var (
+	extraScopes   []string
+	extraScopesMu sync.Mutex
+)
+
+// AddLoginScopes makes the next login also request scopes
+func AddLoginScopes(scopes ...string) {
+	extraScopesMu.Lock()
+	defer extraScopesMu.Unlock()
+	extraScopes = append(extraScopes, scopes...)
+}
+
+// LoginScopes returns DefaultScopes plus the added scopes, without duplicates
+func LoginScopes() []string {
+	extraScopesMu.Lock()
+	defer extraScopesMu.Unlock()
+
+	seen := map[string]bool{}
+	var scopes []string
+	for _, scope := range append(append([]string{}, DefaultScopes...), extraScopes...) {
+		if scope != "" && !seen[scope] {
+			seen[scope] = true
+			scopes = append(scopes, scope)
+		}
+	}
+	return scopes
+}
+
+// Scopes returns the scopes the token was granted
+func (t *SpotfiyToken) Scopes() []string {
+	return strings.Fields(t.Scope)
+}
+
+// MissingScopes returns the scopes in needed that the token was not granted. A token
+// with no recorded scope is assumed to have them all, since there is nothing to check.
+func (t *SpotfiyToken) MissingScopes(needed []string) []string {
+	granted := t.Scopes()
+	if len(granted) == 0 {
+		return nil
+	}
+
+	var missing []string
+	for _, scope := range needed {
+		found := false
+		for _, g := range granted {
+			if g == scope {
+				found = true
+				break
+			}
+		}
+		if !found {
+			missing = append(missing, scope)
+		}
+	}
+	return missing
+}
@@ -1,50 +1,112 @@
 package utils
 
 import (
-	"encoding/json"
+	"bytes"
+	"context"
+	"errors"
 	"fmt"
 	"io"
+	"io/fs"
 	"net/http"
-	"os"
+	"sync"
+	"time"
 )
 
-// SpotifyClient handles all Spotify API requests
+// SpotifyClient handles all Spotify API requests. It is safe for concurrent use and
+// meant to live for the whole process: the token is kept in memory and only re-read
+// from disk when it needs refreshing.
 type SpotifyClient struct {
 	HTTPClient *http.Client
 	Token      *SpotfiyToken
+	tokenMu    sync.Mutex // guards Token
+
+	// APIBaseURL is prepended to Web API paths, e.g. "https://api.spotify.com/v1"
+	APIBaseURL string
+	// AccountsBaseURL is used for token refreshes, e.g. "https://accounts.spotify.com"
+	AccountsBaseURL string
+
+	// Retry decides how 429 and 502/503/504 responses are retried
+	Retry RetryPolicy
 }
 
 // NewSpotifyClient loads the saved token and initializes a client
 func NewSpotifyClient() (*SpotifyClient, error) {
-	data, err := os.ReadFile("token.json")
-	if err != nil {
-		return nil, fmt.Errorf("token.json not found, please login first: %v", err)
+	token, err := loadToken()
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, fmt.Errorf("no saved credentials, please login first")
 	}
-
-	var token SpotfiyToken
-	if err := json.Unmarshal(data, &token); err != nil {
-		return nil, fmt.Errorf("failed to parse token.json: %v", err)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load credentials: %w", err)
 	}
 
 	return &SpotifyClient{
-		HTTPClient: &http.Client{},
-		Token:      &token,
+		HTTPClient:      &http.Client{Timeout: 30 * time.Second},
+		Token:           token,
+		APIBaseURL:      APIBaseURL,
+		AccountsBaseURL: AccountsBaseURL,
+		Retry:           DefaultRetryPolicy,
 	}, nil
 }
 
-// makeRequest is an internal helper that automatically refreshes the token if expired
-func (s *SpotifyClient) makeRequest(method, url string, body io.Reader) (*http.Response, error) {
-	req, err := http.NewRequest(method, url, body)
-	if err != nil {
-		return nil, err
+// APIURL builds a full Web API URL from a path such as "/me/player/play"
+func (s *SpotifyClient) APIURL(path string) string {
+	return s.APIBaseURL + path
+}
+
+// makeRequest is an internal helper that refreshes the token when needed and retries
+// rate-limited or transiently failing requests according to s.Retry
+func (s *SpotifyClient) makeRequest(ctx context.Context, method, url string, body io.Reader) (*http.Response, error) {
+	// Buffer the body so it can be replayed on every attempt
+	var payload []byte
+	if body != nil {
+		var err error
+		if payload, err = io.ReadAll(body); err != nil {
+			return nil, err
+		}
 	}
 
-	req.Header.Set("Authorization", "Bearer "+s.Token.AccessToken)
-	if method == http.MethodPost || method == http.MethodPut {
-    	req.Header.Set("Content-Type", "application/json")
+	for attempt := 0; ; attempt++ {
+		resp, err := s.doAuthorized(ctx, method, url, payload)
+		if err != nil {
+			return nil, err
+		}
+		if !isRetryable(method, resp.StatusCode) {
+			return resp, nil
+		}
+		resp.Body.Close()
+
+		wait := s.Retry.backoff(attempt)
+		after := retryAfter(resp)
+		if resp.StatusCode == http.StatusTooManyRequests && after > 0 {
+			wait = after
+		}
+
+		// Give up when the budget is spent or the server wants us to wait longer than we're willing to
+		if attempt >= s.Retry.MaxRetries || wait > s.Retry.MaxDelay {
+			return nil, &RetryError{StatusCode: resp.StatusCode, Attempts: attempt + 1, RetryAfter: after}
+		}
+
+		timer := time.NewTimer(wait)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return nil, ctx.Err()
+		case <-timer.C:
+		}
+	}
+}
+
+// doAuthorized sends one request, refreshing the token ahead of expiry and
+// retrying once if the API still answers 401
+func (s *SpotifyClient) doAuthorized(ctx context.Context, method, url string, payload []byte) (*http.Response, error) {
+	if token := s.currentToken(); token.expiresSoon() {
+		if err := s.refresh(ctx, token.AccessToken); err != nil {
+			return nil, fmt.Errorf("failed to refresh token: %w", err)
+		}
 	}
 
-	resp, err := s.HTTPClient.Do(req)
+	sentWith := s.currentToken().AccessToken
+	resp, err := s.send(ctx, method, url, payload)
 	if err != nil {
 		return nil, err
 	}
@@ -52,43 +114,119 @@ func (s *SpotifyClient) makeRequest(method, url string, body io.Reader) (*http.R
 	// If access token expired
 	if resp.StatusCode == http.StatusUnauthorized {
 		resp.Body.Close()
-		fmt.Println("Access token expired. Refreshing...")
 
-		// Call  existing RefreshToken() function
-		if err := RefreshToken(); err != nil {
-			return nil, fmt.Errorf("failed to refresh token: %v", err)
+		if err := s.refresh(ctx, sentWith); err != nil {
+			return nil, fmt.Errorf("failed to refresh token: %w", err)
 		}
 
-		// Reload updated token
-		updated, err := os.ReadFile("token.json")
-		if err != nil {
-			return nil, err
-		}
-		var newToken SpotfiyToken
-		if err := json.Unmarshal(updated, &newToken); err != nil {
-			return nil, err
-		}
-		s.Token = &newToken
-
 		// Retry the same request once
-		req.Header.Set("Authorization", "Bearer "+s.Token.AccessToken)
-		return s.HTTPClient.Do(req)
+		return s.send(ctx, method, url, payload)
 	}
 
 	return resp, nil
 }
 
+func (s *SpotifyClient) send(ctx context.Context, method, url string, payload []byte) (*http.Response, error) {
+	var body io.Reader
+	if payload != nil {
+		body = bytes.NewReader(payload)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, url, body)
+	if err != nil {
+		return nil, err
+	}
+
+	req.Header.Set("Authorization", "Bearer "+s.currentToken().AccessToken)
+	if method == http.MethodPost || method == http.MethodPut {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	return s.HTTPClient.Do(req)
+}
+
+func (s *SpotifyClient) currentToken() *SpotfiyToken {
+	s.tokenMu.Lock()
+	defer s.tokenMu.Unlock()
+	return s.Token
+}
+
+func (s *SpotifyClient) setToken(token *SpotfiyToken) {
+	s.tokenMu.Lock()
+	defer s.tokenMu.Unlock()
+	s.Token = token
+}
+
+// refresh replaces a stale access token. Refreshes are single-flight: if another
+// goroutine already refreshed this client, or another process stored a newer token
+// on disk, while we waited for the lock, that token is reused instead.
+func (s *SpotifyClient) refresh(ctx context.Context, stale string) error {
+	refreshMu.Lock()
+	defer refreshMu.Unlock()
+
+	if current := s.currentToken(); current.AccessToken != stale && !current.expiresSoon() {
+		return nil
+	}
+
+	current, err := loadToken()
+	if err == nil && current.AccessToken != stale && !current.expiresSoon() {
+		s.setToken(current)
+		return nil
+	}
+
+	if err := refreshTokenLocked(ctx, s.AccountsBaseURL); err != nil {
+		return err
+	}
+
+	current, err = loadToken()
+	if err != nil {
+		return err
+	}
+	s.setToken(current)
+	return nil
+}
+
+// CheckResponse returns an *APIError for any non-2xx response so callers don't try to
+// decode an error body as data. The body is left for the caller to close.
+func CheckResponse(resp *http.Response) error {
+	if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+		return nil
+	}
+	return parseAPIError(resp)
+}
+
 // Public helper for GET requests
 func (s *SpotifyClient) Get(url string) (*http.Response, error) {
-	return s.makeRequest(http.MethodGet, url, nil)
+	return s.GetContext(context.Background(), url)
 }
 
-// Public helper for POST requests (optional, for later)
+// Public helper for POST requests
 func (s *SpotifyClient) Post(url string, body io.Reader) (*http.Response, error) {
-	return s.makeRequest(http.MethodPost, url, body)
+	return s.PostContext(context.Background(), url, body)
 }
 
 func (s *SpotifyClient) Put(url string, body io.Reader) (*http.Response, error) {
-    return s.makeRequest(http.MethodPut, url, body)
+	return s.PutContext(context.Background(), url, body)
+}
+
+func (s *SpotifyClient) Delete(url string, body io.Reader) (*http.Response, error) {
+	return s.DeleteContext(context.Background(), url, body)
 }
 
+// The Context variants abort the request, including retry waits, when ctx is cancelled
+
+func (s *SpotifyClient) GetContext(ctx context.Context, url string) (*http.Response, error) {
+	return s.makeRequest(ctx, http.MethodGet, url, nil)
+}
+
+func (s *SpotifyClient) PostContext(ctx context.Context, url string, body io.Reader) (*http.Response, error) {
+	return s.makeRequest(ctx, http.MethodPost, url, body)
+}
+
+func (s *SpotifyClient) PutContext(ctx context.Context, url string, body io.Reader) (*http.Response, error) {
+	return s.makeRequest(ctx, http.MethodPut, url, body)
+}
+
+func (s *SpotifyClient) DeleteContext(ctx context.Context, url string, body io.Reader) (*http.Response, error) {
+	return s.makeRequest(ctx, http.MethodDelete, url, body)
+}
@@ -8,6 +8,9 @@ require (
 	github.com/charmbracelet/lipgloss v0.13.0
 	github.com/joho/godotenv v1.5.1
 	github.com/spf13/cobra v1.10.1
+	golang.org/x/crypto v0.32.0
+	golang.org/x/term v0.28.0
+	gopkg.in/yaml.v3 v3.0.1
 )
 
 require (
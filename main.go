@@ -1,11 +1,14 @@
 /*
 Copyright © 2025 NAME HERE <EMAIL ADDRESS>
-
 */
 package main
 
-import "github.com/adi-253/Gitify/cmd"
+import (
+	"os"
+
+	"github.com/adi-253/Gitify/cmd"
+)
 
 func main() {
-	cmd.Execute()
+	os.Exit(cmd.Execute())
 }